@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/liam-witterick/jira-worklogger/holidays"
+)
+
+// Reasons a day is not a working day
+const (
+	DayOffWeekend = "weekend" // Not one of calendar.working_days
+	DayOffHoliday = "holiday"
+	DayOffPTO     = "pto"
+)
+
+// defaultWorkingDays are worked when calendar.working_days is not set
+var defaultWorkingDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// Calendar knows which days are working days and how much time is expected on them
+type Calendar struct {
+	workingDays map[time.Weekday]bool
+	targets     map[time.Weekday]int
+	holidays    map[string]string // Holiday names by date
+	pto         []DateRange
+}
+
+// NewCalendar builds the working calendar from the calendar section of the settings, loading
+// the bundled holidays of the country and the holiday files
+func NewCalendar(settings *Settings) (*Calendar, error) {
+	config := settings.Calendar
+	calendar := &Calendar{
+		workingDays: map[time.Weekday]bool{},
+		targets:     map[time.Weekday]int{},
+		holidays:    map[string]string{},
+	}
+
+	for _, name := range config.WorkingDays {
+		weekday, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid calendar.working_days day %q, expected e.g. mon", name)
+		}
+		calendar.workingDays[weekday] = true
+	}
+	if len(config.WorkingDays) == 0 {
+		for _, weekday := range defaultWorkingDays {
+			calendar.workingDays[weekday] = true
+		}
+	}
+
+	dailyTarget := settings.DailyTargetSeconds()
+	for weekday := range calendar.workingDays {
+		calendar.targets[weekday] = dailyTarget
+	}
+	for name, target := range config.Targets {
+		weekday, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("invalid calendar.targets day %q, expected e.g. fri", name)
+		}
+		seconds, err := ToTimeSpentSeconds(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar.targets.%s: %v", name, err)
+		}
+		calendar.targets[weekday] = seconds
+	}
+
+	if config.Country != "" {
+		countryHolidays, err := holidays.Country(config.Country)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar.country: %v", err)
+		}
+		calendar.addHolidays(countryHolidays)
+	}
+	for _, filename := range config.HolidayFiles {
+		fileHolidays, err := holidays.LoadICS(filename)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar.holiday_files: %v", err)
+		}
+		calendar.addHolidays(fileHolidays)
+	}
+	for _, holiday := range config.Holidays {
+		date, err := time.Parse("2006-01-02", holiday)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar.holidays date %q, expected YYYY-MM-DD", holiday)
+		}
+		calendar.addHolidays([]holidays.Holiday{{Date: date.Format("2006-01-02")}})
+	}
+
+	for _, pto := range config.PTO {
+		dates, err := ParseDateRange(pto, DefaultDateStr())
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar.pto: %v", err)
+		}
+		calendar.pto = append(calendar.pto, dates)
+	}
+	return calendar, nil
+}
+
+// WorkCalendar returns the working calendar of the settings, built when they were loaded
+func (s *Settings) WorkCalendar() *Calendar {
+	if s.calendar == nil {
+		calendar, err := NewCalendar(s)
+		if err != nil {
+			calendar, _ = NewCalendar(&Settings{DefaultsConfig: s.DefaultsConfig})
+		}
+		s.calendar = calendar
+	}
+	return s.calendar
+}
+
+// addHolidays adds holidays, keeping the first name of a date
+func (c *Calendar) addHolidays(list []holidays.Holiday) {
+	for _, holiday := range list {
+		if c.holidays[holiday.Date] == "" {
+			c.holidays[holiday.Date] = holiday.Name
+		}
+	}
+}
+
+// DayOff returns why a date is not a working day, or "" for working days
+func (c *Calendar) DayOff(date time.Time) string {
+	if _, ok := c.holidays[date.Format("2006-01-02")]; ok {
+		return DayOffHoliday
+	}
+	if c.onPTO(date) {
+		return DayOffPTO
+	}
+	if !c.workingDays[date.Weekday()] {
+		return DayOffWeekend
+	}
+	return ""
+}
+
+// DescribeDayOff describes why a date is not a working day, e.g. "a holiday (Christmas Day)",
+// or returns "" for working days
+func (c *Calendar) DescribeDayOff(date time.Time) string {
+	switch c.DayOff(date) {
+	case DayOffHoliday:
+		if name := c.holidays[date.Format("2006-01-02")]; name != "" {
+			return fmt.Sprintf("a holiday (%s)", name)
+		}
+		return "a holiday"
+	case DayOffPTO:
+		return "a day of PTO"
+	case DayOffWeekend:
+		return fmt.Sprintf("not a working day (%s)", date.Format("Monday"))
+	}
+	return ""
+}
+
+// TargetSeconds returns the time expected to be logged on a date, nothing on days off
+func (c *Calendar) TargetSeconds(date time.Time) int {
+	if c.DayOff(date) != "" {
+		return 0
+	}
+	return c.targets[date.Weekday()]
+}
+
+// ScheduledSeconds returns the time a date is normally worked, ignoring PTO: the target of
+// working days that are not holidays
+func (c *Calendar) ScheduledSeconds(date time.Time) int {
+	if _, ok := c.holidays[date.Format("2006-01-02")]; ok || !c.workingDays[date.Weekday()] {
+		return 0
+	}
+	return c.targets[date.Weekday()]
+}
+
+// onPTO reports whether a date falls in a personal time off range
+func (c *Calendar) onPTO(date time.Time) bool {
+	for _, dates := range c.pto {
+		if !date.Before(dates.From) && !date.After(dates.To) {
+			return true
+		}
+	}
+	return false
+}
+
+// WarnNonWorkingDays warns about entries logged on days off, which are posted anyway
+func WarnNonWorkingDays(settings *Settings, logger *Logger, days []DayEntries) {
+	calendar := settings.WorkCalendar()
+	for _, day := range days {
+		date, err := ParseDate(day.Date)
+		if err != nil {
+			continue
+		}
+		if reason := calendar.DescribeDayOff(date); reason != "" {
+			total := 0
+			for _, entry := range day.Entries {
+				total += entry.Seconds
+			}
+			logger.Warn("%s is %s, logging %s on it anyway", day.Date, reason, FormatSeconds(total))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCalendar(t *testing.T) {
+	companyICS := filepath.Join(t.TempDir(), "company.ics")
+	if err := os.WriteFile(companyICS, []byte("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20251223\nSUMMARY:Company day\nEND:VEVENT\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := &Settings{}
+	settings.DailyTarget = "7.5h"
+	settings.Calendar = CalendarConfig{
+		WorkingDays:  []string{"Mon", "tue", "wed", "thu", "fri", "sat"},
+		Targets:      map[string]string{"fri": "4h", "sat": "2h"},
+		Country:      "gb",
+		HolidayFiles: []string{companyICS},
+		Holidays:     []string{"2025-12-24"},
+		PTO:          []string{"2025-12-29..2025-12-31", "2025-09-12"},
+	}
+	calendar, err := NewCalendar(settings)
+	if err != nil {
+		t.Fatalf("NewCalendar: %v", err)
+	}
+
+	tests := []struct {
+		date     string
+		dayOff   string
+		target   int
+		describe string
+	}{
+		{"2025-12-22", "", 27000, ""},
+		{"2025-12-23", DayOffHoliday, 0, "a holiday (Company day)"},
+		{"2025-12-24", DayOffHoliday, 0, "a holiday"},
+		{"2025-12-25", DayOffHoliday, 0, "a holiday (Christmas Day)"},
+		{"2025-12-27", "", 7200, ""},
+		{"2025-12-28", DayOffWeekend, 0, "not a working day (Sunday)"},
+		{"2025-12-29", DayOffPTO, 0, "a day of PTO"},
+		{"2025-12-31", DayOffPTO, 0, "a day of PTO"},
+		{"2026-01-01", DayOffHoliday, 0, "a holiday (New Year's Day)"},
+		{"2026-01-02", "", 14400, ""},
+		{"2025-09-12", DayOffPTO, 0, "a day of PTO"},
+	}
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		if got := calendar.DayOff(date); got != tt.dayOff {
+			t.Errorf("DayOff(%s) = %q, want %q", tt.date, got, tt.dayOff)
+		}
+		if got := calendar.TargetSeconds(date); got != tt.target {
+			t.Errorf("TargetSeconds(%s) = %d, want %d", tt.date, got, tt.target)
+		}
+		if got := calendar.DescribeDayOff(date); got != tt.describe {
+			t.Errorf("DescribeDayOff(%s) = %q, want %q", tt.date, got, tt.describe)
+		}
+	}
+
+	for _, config := range []CalendarConfig{
+		{WorkingDays: []string{"someday"}},
+		{Targets: map[string]string{"fri": "lots"}},
+		{Country: "XX"},
+		{HolidayFiles: []string{filepath.Join(t.TempDir(), "missing.ics")}},
+		{Holidays: []string{"25/12/2025"}},
+		{PTO: []string{"2025-12-31..2025-12-29"}},
+	} {
+		settings.Calendar = config
+		if _, err := NewCalendar(settings); err == nil {
+			t.Errorf("NewCalendar(%+v) succeeded, want an error", config)
+		}
+	}
+}
+
+func TestDefaultCalendar(t *testing.T) {
+	settings := &Settings{}
+	settings.DailyTarget = "8h"
+	calendar := settings.WorkCalendar()
+	for date, want := range map[string]int{"2025-12-25": 28800, "2025-12-26": 28800, "2025-12-27": 0} {
+		day, _ := time.Parse("2006-01-02", date)
+		if got := calendar.TargetSeconds(day); got != want {
+			t.Errorf("TargetSeconds(%s) = %d, want %d", date, got, want)
+		}
+	}
+}
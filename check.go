@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CheckResult lists the working days of a range below the daily target
+type CheckResult struct {
+	From       string         `json:"from" yaml:"from"`
+	To         string         `json:"to" yaml:"to"`
+	Checked    int            `json:"checked_days" yaml:"checked_days"` // Working days up to the cutoff
+	GapSeconds int            `json:"gap_seconds" yaml:"gap_seconds"`
+	Gaps       []TimesheetDay `json:"gaps" yaml:"gaps"`
+}
+
+// runCheck reports the working days of a range with less time logged than the daily target,
+// exiting with ExitMissingTime when there are any. Today is skipped unless --include-today is
+// given, as it is usually still being worked.
+func runCheck(args []string) int {
+	spec := ""
+	includeToday := false
+	quiet := false
+	outputFormat := OutputTable
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--include-today":
+			includeToday = true
+		case args[i] == "--quiet":
+			quiet = true
+		case args[i] == "--output" && i+1 < len(args):
+			format, err := ParseOutputFormat(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+				return ExitConfig
+			}
+			outputFormat = format
+			i++
+		case !strings.HasPrefix(args[i], "--") && spec == "":
+			spec = args[i]
+		default:
+			fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger check [RANGE] [--include-today] [--quiet] [--output json|yaml|table]")
+			return ExitConfig
+		}
+	}
+
+	dates, cutoff, err := CheckWindow(spec, DefaultDateStr(), includeToday)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return ExitConfig
+	}
+
+	settings, logger := setup()
+	worklogs, err := GetMyWorklogs(settings, logger, dates.From.Format("2006-01-02"), dates.To.Format("2006-01-02"))
+	if err != nil {
+		logger.Error("Failed to fetch worklogs: %v", err)
+		return ExitCodeFor(err)
+	}
+	result := CheckTimesheet(BuildTimesheet(settings, dates, cutoff, worklogs, nil), cutoff)
+
+	exitCode := ExitOK
+	if len(result.Gaps) > 0 {
+		exitCode = ExitMissingTime
+	}
+
+	if outputFormat != OutputTable {
+		if err := WriteOutput(outputFormat, result); err != nil {
+			logger.Error("Failed to write result: %v", err)
+			return ExitFailure
+		}
+		return exitCode
+	}
+
+	if cutoff < result.From {
+		if !quiet {
+			fmt.Printf("Nothing to check yet, %s to %s is after %s.\n", result.From, result.To, cutoff)
+		}
+		return exitCode
+	}
+	if len(result.Gaps) == 0 {
+		if !quiet {
+			fmt.Printf("All %d working days from %s to %s meet the daily target.\n", result.Checked, result.From, minString(result.To, cutoff))
+		}
+		return exitCode
+	}
+	fmt.Printf("Missing time on %d of %d working days from %s to %s:\n", len(result.Gaps), result.Checked, result.From, minString(result.To, cutoff))
+	for _, day := range result.Gaps {
+		fmt.Printf("  %s %s  logged %-6s of %-6s missing %s\n", day.Date, day.Weekday,
+			FormatSeconds(day.Seconds), FormatSeconds(day.TargetSeconds), FormatSeconds(day.GapSeconds))
+	}
+	fmt.Printf("Total missing: %s\n", FormatSeconds(result.GapSeconds))
+	return exitCode
+}
+
+// CheckWindow resolves the range to check and the last day of it to check. Without a range it is
+// the current week, or the previous one when none of the current week is checked yet, e.g. on a Monday.
+func CheckWindow(spec, todayStr string, includeToday bool) (DateRange, string, error) {
+	today, err := ParseDate(todayStr)
+	if err != nil {
+		return DateRange{}, "", err
+	}
+	cutoff := todayStr
+	if !includeToday {
+		cutoff = today.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	if spec == "" {
+		spec = "this-week"
+		if thisWeek, err := ParseDateRange(spec, todayStr); err == nil && cutoff < thisWeek.From.Format("2006-01-02") {
+			spec = "last-week"
+		}
+	}
+	dates, err := ParseDateRange(spec, todayStr)
+	return dates, cutoff, err
+}
+
+// CheckTimesheet lists the working days of a timesheet up to the cutoff date that are below the daily target
+func CheckTimesheet(timesheet *Timesheet, cutoff string) CheckResult {
+	result := CheckResult{From: timesheet.From, To: timesheet.To, GapSeconds: timesheet.GapSeconds, Gaps: []TimesheetDay{}}
+	for _, day := range timesheet.Days {
+		if day.Date > cutoff || day.TargetSeconds == 0 {
+			continue
+		}
+		result.Checked++
+		if day.GapSeconds > 0 {
+			result.Gaps = append(result.Gaps, day)
+		}
+	}
+	return result
+}
+
+// minString returns the smaller of two strings, e.g. the earlier of two YYYY-MM-DD dates
+func minString(a, b string) string {
+	if b < a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckTimesheet(t *testing.T) {
+	// Monday is complete, Tuesday is short and Wednesday is after the cutoff
+	timesheet := testTimesheet(t)
+
+	tests := []struct {
+		cutoff  string
+		checked int
+		gaps    []string
+	}{
+		{cutoff: "2025-09-07", checked: 0, gaps: []string{}},
+		{cutoff: "2025-09-08", checked: 1, gaps: []string{}},
+		{cutoff: "2025-09-09", checked: 2, gaps: []string{"2025-09-09"}},
+		{cutoff: "2025-09-10", checked: 3, gaps: []string{"2025-09-09"}},
+	}
+	for _, tt := range tests {
+		result := CheckTimesheet(timesheet, tt.cutoff)
+		gaps := []string{}
+		for _, day := range result.Gaps {
+			gaps = append(gaps, day.Date)
+		}
+		if result.Checked != tt.checked || !reflect.DeepEqual(gaps, tt.gaps) {
+			t.Errorf("CheckTimesheet(%s) checked %d days with gaps %v, want %d with %v", tt.cutoff, result.Checked, gaps, tt.checked, tt.gaps)
+		}
+	}
+}
+
+func TestCheckWindow(t *testing.T) {
+	tests := []struct {
+		spec         string
+		today        string
+		includeToday bool
+		from, to     string
+		cutoff       string
+	}{
+		{spec: "", today: "2025-09-10", from: "2025-09-08", to: "2025-09-14", cutoff: "2025-09-09"},
+		{spec: "", today: "2025-09-08", from: "2025-09-01", to: "2025-09-07", cutoff: "2025-09-07"},
+		{spec: "", today: "2025-09-08", includeToday: true, from: "2025-09-08", to: "2025-09-14", cutoff: "2025-09-08"},
+		{spec: "this-week", today: "2025-09-08", from: "2025-09-08", to: "2025-09-14", cutoff: "2025-09-07"},
+	}
+	for _, tt := range tests {
+		dates, cutoff, err := CheckWindow(tt.spec, tt.today, tt.includeToday)
+		if err != nil {
+			t.Fatalf("CheckWindow(%q, %s): %v", tt.spec, tt.today, err)
+		}
+		from, to := dates.From.Format("2006-01-02"), dates.To.Format("2006-01-02")
+		if from != tt.from || to != tt.to || cutoff != tt.cutoff {
+			t.Errorf("CheckWindow(%q, %s, %v) = %s..%s up to %s, want %s..%s up to %s",
+				tt.spec, tt.today, tt.includeToday, from, to, cutoff, tt.from, tt.to, tt.cutoff)
+		}
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	newTestSettings(t, `defaults:
+  daily_target: 7.5h
+calendar:
+  holidays: [2025-09-10]
+  pto: [2025-09-11..2025-09-12]
+`)
+
+	// The default fixture has an hour logged on Monday, the rest of the week is a holiday or PTO
+	var result CheckResult
+	output := captureStdout(t, func() {
+		if code := runCheck([]string{"2025-W37", "--output", "json"}); code != ExitMissingTime {
+			t.Errorf("runCheck exited with %d, want %d", code, ExitMissingTime)
+		}
+	})
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("invalid JSON %q: %v", output, err)
+	}
+	want := []TimesheetDay{
+		{Date: "2025-09-08", Weekday: "Mon", Seconds: 3600, TargetSeconds: 27000, GapSeconds: 23400},
+		{Date: "2025-09-09", Weekday: "Tue", TargetSeconds: 27000, GapSeconds: 27000},
+	}
+	if result.Checked != 2 || result.GapSeconds != 50400 || !reflect.DeepEqual(result.Gaps, want) {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	output = captureStdout(t, func() {
+		if code := runCheck([]string{"2025-09-13..2025-09-14", "--quiet"}); code != ExitOK {
+			t.Errorf("runCheck of a weekend exited with %d, want %d", code, ExitOK)
+		}
+		if code := runCheck([]string{"2999-01-01"}); code != ExitOK {
+			t.Errorf("runCheck of a future day exited with %d, want %d", code, ExitOK)
+		}
+		if code := runCheck([]string{"last-week", "this-week"}); code != ExitConfig {
+			t.Errorf("runCheck with two ranges exited with %d, want %d", code, ExitConfig)
+		}
+	})
+	if !strings.Contains(output, "Nothing to check yet, 2999-01-01 to 2999-01-01") {
+		t.Errorf("expected nothing to check for a future day, got %q", output)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// subcommands maps subcommand names to their handlers, which return the exit code
+var subcommands = map[string]func(args []string) int{
+	"start":   runTimerStart,
+	"stop":    runTimerStop,
+	"switch":  runTimerSwitch,
+	"status":  runTimerStatus,
+	"discard": runTimerDiscard,
+	"post":    runTimerPost,
+	"history": runHistory,
+	"undo":    runUndo,
+	"retry":   runRetry,
+	"report":  runReport,
+	"list":    runList,
+	"doctor":  runDoctor,
+	"check":   runCheck,
+	"leave":   runLeave,
+
+	"mock-server": runMockServer,
+}
+
+// setup loads the settings and creates the logger, exiting on configuration errors
+func setup() (*Settings, *Logger) {
+	settings, logger, err := loadSetup()
+	if err != nil {
+		logSetupError(logger, err)
+		os.Exit(ExitConfig)
+	}
+	return settings, logger
+}
+
+// loadSetup loads the settings and creates the logger, returning configuration errors. The
+// logger is returned with the error when it could be created.
+func loadSetup() (*Settings, *Logger, error) {
+	// Load settings
+	settings, err := LoadSettings()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Initialize logger
+	if err := ConfigureLogging(settings); err != nil {
+		return nil, nil, err
+	}
+	logger := NewLogger(settings.LogLevel)
+
+	// Check for placeholder API token
+	if settings.JiraAPIToken == "YOUR_API_TOKEN" {
+		return nil, logger, fmt.Errorf("please update your API token in worklog_config.yaml - it's currently set to the placeholder value 'YOUR_API_TOKEN'")
+	}
+
+	return settings, logger, nil
+}
+
+// logSetupError logs an error of loadSetup, to stderr when there is no logger yet
+func logSetupError(logger *Logger, err error) {
+	if logger != nil {
+		logger.Error("%v", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+}
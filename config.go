@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 
@@ -10,18 +11,89 @@ import (
 
 // Settings represents the configuration for the application
 type Settings struct {
-	JiraBaseURL      string `yaml:"jira_base_url"`
-	JiraEmailOrUser  string `yaml:"jira_email"`
-	JiraAPIToken     string `yaml:"jira_api_token"`
-	Timezone         string `yaml:"timezone"`
-	APIVersion       string `yaml:"api_version"`
-	LogLevel         string `yaml:"log_level"`
-	DefaultsConfig   `yaml:"defaults"`
+	JiraBaseURL     string `yaml:"jira_base_url"`
+	JiraEmailOrUser string `yaml:"jira_email"`
+	JiraAPIToken    string `yaml:"jira_api_token"`
+	Timezone        string `yaml:"timezone"`
+	APIVersion      string `yaml:"api_version"`
+	LogLevel        string `yaml:"log_level"`
+	DefaultsConfig  `yaml:"defaults"`
+	Timer           TimerConfig         `yaml:"timer"`
+	Logging         LoggingConfig       `yaml:"logging"`
+	Calendar        CalendarConfig      `yaml:"calendar"`
+	Leave           map[string]string   `yaml:"leave"`     // Leave types mapped to the issue or alias absences are logged to
+	Recurring       []RecurringConfig   `yaml:"recurring"` // Entries added to every matching day
+	Presets         map[string][]string `yaml:"presets"`   // Named lists of alias=duration lines, used with --preset
+
+	calendar  *Calendar               // Built from Calendar when the settings are loaded
+	recurring []RecurringRule         // Parsed from Recurring when the settings are loaded
+	presets   map[string][]PresetLine // Parsed from Presets when the settings are loaded
 }
 
 // DefaultsConfig represents the defaults section of the config
 type DefaultsConfig struct {
 	CategoryAliases map[string]string `yaml:"category_aliases"`
+	DailyTarget     string            `yaml:"daily_target"` // Hours expected per working day, e.g. 7.5h
+
+	// Statuses in which logging time is not allowed, checked before posting (default
+	// defaultDisallowedStatuses, [] allows any status)
+	DisallowedStatuses []string `yaml:"disallowed_statuses"`
+}
+
+// defaultDisallowedStatuses are the statuses of finished issues, which GetAssignedIssues leaves out too
+var defaultDisallowedStatuses = []string{"Done", "Closed", "Completed", "Wasted"}
+
+// TimerConfig represents the timer section of the config
+type TimerConfig struct {
+	Rounding     string `yaml:"rounding"`      // Increment worklogs are rounded to, e.g. 15m (default 1m)
+	RoundingMode string `yaml:"rounding_mode"` // up, down or nearest (default)
+}
+
+// CalendarConfig represents the calendar section of the config
+type CalendarConfig struct {
+	WorkingDays  []string          `yaml:"working_days"`  // Weekdays worked (default mon to fri)
+	Targets      map[string]string `yaml:"targets"`       // Hours expected per weekday, e.g. fri: 4h (default defaults.daily_target)
+	Country      string            `yaml:"country"`       // Bundled public holidays, e.g. GB
+	HolidayFiles []string          `yaml:"holiday_files"` // iCalendar (.ics) files of holidays
+	Holidays     []string          `yaml:"holidays"`      // Public holidays, YYYY-MM-DD
+	PTO          []string          `yaml:"pto"`           // Personal time off, a date or FROM..TO
+}
+
+// RecurringConfig represents a rule of the recurring section of the config, e.g. a daily standup
+type RecurringConfig struct {
+	Name      string   `yaml:"name"`       // Shown in the review, defaults to the alias
+	Alias     string   `yaml:"alias"`      // Issue key or category alias
+	Duration  string   `yaml:"duration"`   // e.g. 15m
+	Days      []string `yaml:"days"`       // Weekdays, e.g. [mon, wed] (default every working day)
+	Interval  int      `yaml:"interval"`   // Every N weeks, counted from start_date (default 1)
+	StartDate string   `yaml:"start_date"` // First day of the rule, YYYY-MM-DD
+	EndDate   string   `yaml:"end_date"`   // Optional last day of the rule, YYYY-MM-DD
+	Start     string   `yaml:"start"`      // Local start time, HH:MM (default 17:00)
+	Comment   string   `yaml:"comment"`
+}
+
+// LoggingConfig represents the logging section of the config
+type LoggingConfig struct {
+	Format         string `yaml:"format"`           // Console format: text (default) or json
+	File           string `yaml:"file"`             // Optional JSON log file, written at the same level
+	FileMaxSize    string `yaml:"file_max_size"`    // Size the log file is rotated at (default 10MB)
+	FileMaxBackups int    `yaml:"file_max_backups"` // Rotated files kept (default 3)
+	RedactEmails   bool   `yaml:"redact_emails"`    // Also hide email addresses in logs
+}
+
+// Profile identifies the Jira account the settings post to, e.g. user@company.atlassian.net
+func (s *Settings) Profile() string {
+	host := s.JiraBaseURL
+	if parsed, err := url.Parse(s.JiraBaseURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	return fmt.Sprintf("%s@%s", s.JiraEmailOrUser, host)
+}
+
+// DailyTargetSeconds returns the daily target in seconds
+func (s *Settings) DailyTargetSeconds() int {
+	seconds, _ := ToTimeSpentSeconds(s.DailyTarget)
+	return seconds
 }
 
 // LoadSettings loads the application settings from the config file
@@ -31,7 +103,7 @@ func LoadSettings() (*Settings, error) {
 		return nil, fmt.Errorf("no config file found")
 	}
 
-	fmt.Printf("[info] Using config file: %s\n", configPath)
+	fmt.Fprintf(logOutput, "[info] Using config file: %s\n", configPath)
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, err
@@ -62,6 +134,12 @@ func LoadSettings() (*Settings, error) {
 	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
 		settings.LogLevel = logLevel
 	}
+	if logFormat := os.Getenv("LOG_FORMAT"); logFormat != "" {
+		settings.Logging.Format = logFormat
+	}
+	if logFile := os.Getenv("LOG_FILE"); logFile != "" {
+		settings.Logging.File = logFile
+	}
 
 	// Validate required settings
 	if settings.JiraBaseURL == "" {
@@ -82,6 +160,44 @@ func LoadSettings() (*Settings, error) {
 	if settings.LogLevel == "" {
 		settings.LogLevel = "info"
 	}
+	if settings.DailyTarget == "" {
+		settings.DailyTarget = "7.5h"
+	}
+	if settings.DisallowedStatuses == nil {
+		settings.DisallowedStatuses = defaultDisallowedStatuses
+	}
+	if _, err := ToTimeSpentSeconds(settings.DailyTarget); err != nil {
+		return nil, fmt.Errorf("invalid defaults.daily_target: %v", err)
+	}
+	switch settings.Timer.RoundingMode {
+	case "":
+		settings.Timer.RoundingMode = "nearest"
+	case "up", "down", "nearest":
+	default:
+		return nil, fmt.Errorf("invalid timer.rounding_mode %q, expected up, down or nearest", settings.Timer.RoundingMode)
+	}
+	if settings.calendar, err = NewCalendar(settings); err != nil {
+		return nil, err
+	}
+	if settings.recurring, err = NewRecurringRules(settings); err != nil {
+		return nil, err
+	}
+	if settings.presets, err = NewPresets(settings); err != nil {
+		return nil, err
+	}
+	switch settings.Logging.Format {
+	case "":
+		settings.Logging.Format = "text"
+	case "text", "json":
+	default:
+		return nil, fmt.Errorf("invalid logging.format %q, expected text or json", settings.Logging.Format)
+	}
+	if settings.Logging.FileMaxSize == "" {
+		settings.Logging.FileMaxSize = "10MB"
+	}
+	if settings.Logging.FileMaxBackups == 0 {
+		settings.Logging.FileMaxBackups = 3
+	}
 
 	return settings, nil
 }
@@ -126,6 +242,6 @@ func findConfigFile() string {
 		return systemConfig
 	}
 
-	fmt.Println("[warn] No config file found. Checking: environment variable WORKLOG_CONFIG, ./worklog_config.yaml, executable_dir/worklog_config.yaml, ~/.worklog_config.yaml, /etc/jira-worklogger/worklog_config.yaml")
+	fmt.Fprintln(logOutput, "[warn] No config file found. Checking: environment variable WORKLOG_CONFIG, ./worklog_config.yaml, executable_dir/worklog_config.yaml, ~/.worklog_config.yaml, /etc/jira-worklogger/worklog_config.yaml")
 	return ""
 }
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FormatEntriesText formats entries in the text entry format, one date header per day,
+// so they can be edited and parsed again
+func FormatEntriesText(days []DayEntries) string {
+	var b strings.Builder
+	for _, day := range days {
+		fmt.Fprintf(&b, "%s:\n", day.Date)
+		for _, item := range entryItems(day.Entries) {
+			fmt.Fprintf(&b, "%s\n", item)
+		}
+	}
+	return b.String()
+}
+
+// formatEntriesInline formats entries on a single line for the prompt
+func formatEntriesInline(days []DayEntries) string {
+	parts := []string{}
+	for _, day := range days {
+		parts = append(parts, fmt.Sprintf("%s: %s", day.Date, strings.Join(entryItems(day.Entries), "; ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// entryItems formats entries as alias=duration items followed by their start time, visibility
+// and comment, preferring the alias the user typed
+func entryItems(entries []TimeEntry) []string {
+	items := []string{}
+	for _, entry := range entries {
+		name := entry.Issue
+		if entry.Alias != "" {
+			name = entry.Alias
+		}
+		item := fmt.Sprintf("%s=%s", name, FormatSeconds(entry.Seconds))
+		if entry.Start != "" {
+			item += " @" + entry.Start
+		}
+		if entry.Visibility != nil {
+			item += fmt.Sprintf(" [%s:%s]", entry.Visibility.Type, entry.Visibility.Value)
+		}
+		if entry.Comment != "" {
+			item += " " + quoteComment(entry.Comment)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// RenderReviewTable renders the entries about to be posted with the resolved issue, its summary,
+// the start time, the duration and the running total of each day against its target in the
+// working calendar. logged holds the time already logged per date.
+func RenderReviewTable(days []DayEntries, summaries map[string]string, logged map[string]int, calendar *Calendar) string {
+	var b strings.Builder
+
+	for _, day := range days {
+		dayTotal := logged[day.Date]
+		target := 0
+		notes := []string{}
+		if date, err := ParseDate(day.Date); err == nil {
+			target = calendar.TargetSeconds(date)
+			if reason := calendar.DescribeDayOff(date); reason != "" {
+				notes = append(notes, reason)
+			}
+		}
+		if dayTotal > 0 {
+			notes = append(notes, fmt.Sprintf("%s already logged", FormatSeconds(dayTotal)))
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, "%s (%s)\n", day.Date, strings.Join(notes, ", "))
+		} else {
+			fmt.Fprintf(&b, "%s\n", day.Date)
+		}
+		fmt.Fprintf(&b, "  %-12s %-12s %-32s %-7s %-8s %s\n", "ENTRY", "ISSUE", "SUMMARY", "START", "DURATION", "DAY TOTAL")
+
+		for _, entry := range day.Entries {
+			dayTotal += entry.Seconds
+
+			name := entry.Issue
+			if entry.Alias != "" {
+				name = entry.Alias
+			}
+			summary := summaries[strings.ToUpper(entry.Issue)]
+			if summary == "" {
+				summary = "-"
+			} else if len(summary) > 32 {
+				summary = summary[:29] + "..."
+			}
+			start := entry.Started
+			if len(start) >= 16 {
+				start = start[11:16]
+			}
+
+			auto := ""
+			if entry.Recurring != "" {
+				auto = fmt.Sprintf("  auto-added (%s)", entry.Recurring)
+			}
+			fmt.Fprintf(&b, "  %-12s %-12s %-32s %-7s %-8s %s / %s%s\n", name, entry.Issue, summary, start,
+				FormatSeconds(entry.Seconds), FormatSeconds(dayTotal), FormatSeconds(target), auto)
+		}
+
+		switch {
+		case target > 0 && dayTotal > target:
+			fmt.Fprintf(&b, "  %s over target\n", FormatSeconds(dayTotal-target))
+		case target > 0 && dayTotal < target:
+			fmt.Fprintf(&b, "  %s under target\n", FormatSeconds(target-dayTotal))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// ParseEditedEntries parses the entries edited during the review. They go through the same steps
+// as typed entries, except adding the recurring entries again, which would undo removing them:
+// entries matching a recurring rule are marked as such instead.
+func ParseEditedEntries(settings *Settings, logger *Logger, text, dateStr string, recurring bool) ([]TimeEntry, error) {
+	entries, err := ParseTimeEntries(text, dateStr, settings.CategoryAliases, NewLogger("error"))
+	if err != nil {
+		return nil, err
+	}
+	if recurring {
+		MarkRecurring(settings, entries)
+	}
+	return ResolveRemainders(settings, logger, entries), nil
+}
+
+// reviewEntries shows the review table and asks the user to post, edit or abort. Edited text is
+// parsed with reparse. It returns the entries to post, or false when the user aborted.
+func reviewEntries(settings *Settings, logger *Logger, entries []TimeEntry, reparse func(string) ([]TimeEntry, error)) ([]DayEntries, bool) {
+	reader := bufio.NewReader(os.Stdin)
+	summaries := map[string]string{}
+
+	for {
+		days := GroupEntriesByDate(entries)
+		if err := ResolveStartedISO(days, settings.Timezone, logger); err != nil {
+			logger.Error("%v", err)
+			return nil, false
+		}
+
+		// Look up the summaries of issues not seen yet
+		missing := []string{}
+		for _, entry := range entries {
+			if _, ok := summaries[strings.ToUpper(entry.Issue)]; !ok {
+				missing = append(missing, entry.Issue)
+				summaries[strings.ToUpper(entry.Issue)] = ""
+			}
+		}
+		sort.Strings(missing)
+		if found, err := GetIssueSummaries(settings, logger, missing); err != nil {
+			logger.Warn("Failed to load issue summaries: %v", err)
+		} else {
+			for key, summary := range found {
+				summaries[key] = summary
+			}
+		}
+
+		// Include the time already logged in the daily totals
+		logged := map[string]int{}
+		existing, err := GetMyWorklogs(settings, logger, days[0].Date, days[len(days)-1].Date)
+		if err != nil {
+			logger.Warn("Failed to load existing worklogs: %v", err)
+		}
+		for _, w := range existing {
+			logged[w.Date] += w.Seconds
+		}
+
+		fmt.Println("\n=== Review ===")
+		fmt.Print(RenderReviewTable(days, summaries, logged, settings.WorkCalendar()))
+
+		fmt.Print("Post these worklogs? [Y]es / [e]dit / [a]bort: ")
+		answer, readErr := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if readErr != nil && answer == "" {
+			return nil, false
+		}
+
+		switch answer {
+		case "", "y", "yes":
+			return days, true
+		case "a", "abort", "n", "no", "q":
+			return nil, false
+		case "e", "edit":
+			var edited string
+			if EditorCommand() != "" {
+				edited, err = EditEntries(FormatEntriesText(days), func(text string) error {
+					_, err := reparse(text)
+					return err
+				})
+			} else {
+				fmt.Printf("Current entries: %s\n", formatEntriesInline(days))
+				fmt.Print("Time entries: ")
+				edited, err = reader.ReadString('\n')
+			}
+			if err != nil {
+				logger.Error("Failed to edit time entries: %v", err)
+				continue
+			}
+
+			updated, err := reparse(edited)
+			if err != nil {
+				logger.Error("Failed to parse time entries: %v", err)
+				continue
+			}
+			if len(updated) == 0 {
+				logger.Info("No time entries left.")
+				return nil, false
+			}
+			entries = updated
+			WarnNonWorkingDays(settings, logger, GroupEntriesByDate(entries))
+		default:
+			fmt.Println("Please answer y, e or a.")
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+)
+
+func TestFormatEntriesTextRoundTrip(t *testing.T) {
+	aliases := map[string]string{"meetings": "PROJ-123"}
+	days := []DayEntries{
+		{Date: "2025-09-08", Entries: []TimeEntry{
+			{Date: "2025-09-08", Issue: "PROJ-123", Alias: "meetings", Seconds: 3600, Start: "09:30", Comment: "Sprint planning"},
+			{Date: "2025-09-08", Issue: "PROJ-124", Seconds: 5400, Visibility: &jira.Visibility{Type: "role", Value: "Developers"}},
+		}},
+		{Date: "2025-09-09", Entries: []TimeEntry{
+			{Date: "2025-09-09", Issue: "OPS-7", Seconds: 1800, Comment: `Paged: "disk full"; fixed C:\tmp` + "\nsecond line"},
+			{Date: "2025-09-09", Issue: "PROJ-124", Seconds: 900, Start: "8:05", Comment: "review", Visibility: &jira.Visibility{Type: "group", Value: "jira software users"}},
+		}},
+	}
+	want := append(append([]TimeEntry{}, days[0].Entries...), days[1].Entries...)
+
+	for name, text := range map[string]string{
+		"editor": FormatEntriesText(days),
+		"inline": formatEntriesInline(days),
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseTimeEntries(text, "2025-09-10", aliases, NewLogger("error"))
+			if err != nil {
+				t.Fatalf("ParseTimeEntries(%q): %v", text, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip of %q\n got %+v\nwant %+v", text, got, want)
+			}
+		})
+	}
+}
+
+func TestParseEntryOptionsErrors(t *testing.T) {
+	for _, text := range []string{
+		`1h @25:00`,
+		`1h [team:Developers]`,
+		`1h [role:]`,
+		`1h "unterminated`,
+		`1h "comment" @09:00`,
+	} {
+		if _, _, err := parseEntryOptions(text); err == nil {
+			t.Errorf("parseEntryOptions(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+func TestReviewEntriesEdit(t *testing.T) {
+	settings := newTestSettings(t, `
+recurring:
+  - name: standup
+    alias: PROJ-123
+    duration: 15m
+    start: "09:30"
+    comment: Daily standup
+`)
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	console, _ := captureLogs(t, settings)
+	logger := NewLogger("warn")
+
+	// Edit the entries inline, moving OPS-7 to Saturday, then post
+	withStdin(t, "e\nPROJ-123=15m; sat: OPS-7=1h\ny\n")
+	entries := []TimeEntry{{Date: "2025-09-09", Issue: "OPS-7", Seconds: 3600}}
+	var days []DayEntries
+	var confirmed bool
+	captureStdout(t, func() {
+		days, confirmed = reviewEntries(settings, logger, entries, func(text string) ([]TimeEntry, error) {
+			return ParseEditedEntries(settings, logger, text, "2025-09-09", true)
+		})
+	})
+	if !confirmed || len(days) != 2 {
+		t.Fatalf("expected two days confirmed, got %+v, %v", days, confirmed)
+	}
+	if got := days[0].Entries; len(got) != 1 || got[0].Date != "2025-09-06" || got[0].Issue != "OPS-7" {
+		t.Errorf("unexpected entries of %s: %+v", days[0].Date, got)
+	}
+	standup := days[1].Entries[0]
+	if standup.Recurring != "standup" || standup.Start != "09:30" || standup.Comment != "Daily standup" {
+		t.Errorf("expected the edited standup marked as recurring, got %+v", standup)
+	}
+	if !strings.Contains(console.String(), "2025-09-06 is not a working day (Saturday)") {
+		t.Errorf("expected a warning about the edited day off, got:\n%s", console)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CopyEntries returns the worklogs of a day or a week as entries for the target date. A day
+// (yesterday, 2025-09-08) is copied to the target date itself, and the days of a longer range
+// (last-week, 2025-W37) onto the same weekday of the target week. Ranges are relative to the
+// target date. Worklogs added by recurring rules are left out unless withRecurring is set, as
+// the rules add them again, and so are worklogs landing on days off.
+func CopyEntries(settings *Settings, logger *Logger, spec, targetStr string, withRecurring bool) ([]TimeEntry, error) {
+	source, err := ParseDateRange(spec, targetStr)
+	if err != nil {
+		return nil, err
+	}
+	target, err := ParseDate(targetStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(source.Days()) > 7 {
+		return nil, fmt.Errorf("can only copy a day or a week, not %s", source)
+	}
+
+	offset := int(target.Sub(source.From).Hours() / 24)
+	if !source.From.Equal(source.To) {
+		offset = int(weekMonday(target).Sub(weekMonday(source.From)).Hours() / 24)
+	}
+	if offset == 0 {
+		return nil, fmt.Errorf("%s is the period being logged, copy from an earlier day or week", source)
+	}
+
+	fromStr, toStr := source.From.Format("2006-01-02"), source.To.Format("2006-01-02")
+	worklogs, err := GetMyWorklogs(settings, logger, fromStr, toStr)
+	if err != nil {
+		// The ledger only knows what this tool posted, but works offline
+		ledgerWorklogs, ledgerErr := ledgerWorklogs(settings, fromStr, toStr)
+		if ledgerErr != nil || len(ledgerWorklogs) == 0 {
+			return nil, err
+		}
+		logger.Warn("Failed to fetch worklogs from Jira, copying from the local ledger: %v", err)
+		worklogs = ledgerWorklogs
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool { return worklogs[i].Started < worklogs[j].Started })
+
+	calendar := settings.WorkCalendar()
+	aliases := issueAliases(settings.CategoryAliases)
+	entries := []TimeEntry{}
+	index := map[string]int{} // Entries by date and issue
+	for _, worklog := range worklogs {
+		if !withRecurring {
+			if rule, ok := recurringRuleFor(settings, worklog.Date, worklog.Issue, worklog.Seconds); ok {
+				logger.Debug("Not copying %s of %s on %s, added by recurring %s", FormatSeconds(worklog.Seconds), worklog.Issue, worklog.Date, rule.name)
+				continue
+			}
+		}
+		date, err := ParseDate(worklog.Date)
+		if err != nil {
+			continue
+		}
+		date = date.AddDate(0, 0, offset)
+		dateStr := date.Format("2006-01-02")
+		if reason := calendar.DescribeDayOff(date); reason != "" {
+			logger.Info("Not copying %s of %s to %s, it is %s", FormatSeconds(worklog.Seconds), worklog.Issue, dateStr, reason)
+			continue
+		}
+
+		key := dateStr + " " + strings.ToUpper(worklog.Issue)
+		if i, ok := index[key]; ok {
+			entries[i].Seconds += worklog.Seconds
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, TimeEntry{
+			Date:    dateStr,
+			Issue:   worklog.Issue,
+			Alias:   aliases[strings.ToUpper(worklog.Issue)],
+			Seconds: worklog.Seconds,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Date < entries[j].Date })
+	return entries, nil
+}
+
+// ledgerWorklogs returns the worklogs of the settings' profile posted by this tool between two
+// dates (inclusive, YYYY-MM-DD) and not deleted since
+func ledgerWorklogs(settings *Settings, fromDate, toDate string) ([]ExistingWorklog, error) {
+	records, err := ReadLedger()
+	if err != nil {
+		return nil, err
+	}
+	worklogs := []ExistingWorklog{}
+	for _, submission := range LedgerSubmissions(records) {
+		if submission.Profile != settings.Profile() {
+			continue
+		}
+		for _, record := range submission.Active() {
+			if len(record.Started) < len("2006-01-02") {
+				continue
+			}
+			date := record.Started[:len("2006-01-02")]
+			if date < fromDate || date > toDate {
+				continue
+			}
+			worklogs = append(worklogs, ExistingWorklog{
+				ID:      record.WorklogID,
+				Issue:   record.Issue,
+				Date:    date,
+				Started: record.Started,
+				Seconds: record.Seconds,
+			})
+		}
+	}
+	return worklogs, nil
+}
+
+// issueAliases maps issue keys to the first of their category aliases, in alphabetical order
+func issueAliases(aliases map[string]string) map[string]string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	byIssue := map[string]string{}
+	for _, name := range names {
+		issue := strings.ToUpper(aliases[name])
+		if _, ok := byIssue[issue]; !ok {
+			byIssue[issue] = name
+		}
+	}
+	return byIssue
+}
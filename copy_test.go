@@ -0,0 +1,146 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/liam-witterick/jira-worklogger/mockjira"
+)
+
+// copyConfig has a recurring standup, an alias of PROJ-124 and a holiday in 2025-W38
+const copyConfig = `defaults:
+  category_aliases:
+    review: PROJ-124
+    code-review: PROJ-124
+calendar:
+  holidays: [2025-09-19]
+recurring:
+  - name: standup
+    alias: PROJ-123
+    duration: 15m
+`
+
+// postTestWorklogs posts entries to the mock Jira of the settings, recording them in the ledger
+func postTestWorklogs(t *testing.T, settings *Settings, entries []TimeEntry) {
+	t.Helper()
+	days := GroupEntriesByDate(entries)
+	if err := ResolveStartedISO(days, settings.Timezone, NewLogger("error")); err != nil {
+		t.Fatal(err)
+	}
+	for _, day := range days {
+		for _, entry := range day.Entries {
+			result := PostWorklog(settings, NewLogger("error"), entry)
+			if !result.Success {
+				t.Fatalf("failed to post %+v: %s", entry, result.Body)
+			}
+			RecordPosted(settings, NewLogger("error"), "setup", result)
+		}
+	}
+}
+
+// copySource is 2025-W37 on top of the hour of PROJ-124 the default fixture has on Monday
+var copySource = []TimeEntry{
+	{Date: "2025-09-09", Issue: "PROJ-123", Seconds: 1800, Start: "10:00"},
+	{Date: "2025-09-09", Issue: "PROJ-123", Seconds: 900, Start: "09:30"},
+	{Date: "2025-09-10", Issue: "PROJ-124", Seconds: 1800, Start: "09:00"},
+	{Date: "2025-09-10", Issue: "proj-124", Seconds: 1800, Start: "14:00"},
+	{Date: "2025-09-12", Issue: "OPS-7", Seconds: 7200, Start: "09:00"},
+	{Date: "2025-09-13", Issue: "OPS-7", Seconds: 3600, Start: "09:00"},
+}
+
+func TestCopyEntries(t *testing.T) {
+	settings := newTestSettings(t, copyConfig)
+	postTestWorklogs(t, settings, copySource)
+
+	tests := []struct {
+		name          string
+		spec          string
+		target        string
+		withRecurring bool
+		want          []TimeEntry
+	}{
+		{
+			name:   "last week onto the same weekdays",
+			spec:   "last-week",
+			target: "2025-09-17",
+			want: []TimeEntry{
+				{Date: "2025-09-15", Issue: "PROJ-124", Alias: "code-review", Seconds: 3600},
+				{Date: "2025-09-16", Issue: "PROJ-123", Seconds: 1800},
+				{Date: "2025-09-17", Issue: "PROJ-124", Alias: "code-review", Seconds: 3600},
+			},
+		},
+		{
+			name:   "an ISO week onto a later week",
+			spec:   "2025-W37",
+			target: "2025-10-02",
+			want: []TimeEntry{
+				{Date: "2025-09-29", Issue: "PROJ-124", Alias: "code-review", Seconds: 3600},
+				{Date: "2025-09-30", Issue: "PROJ-123", Seconds: 1800},
+				{Date: "2025-10-01", Issue: "PROJ-124", Alias: "code-review", Seconds: 3600},
+				{Date: "2025-10-03", Issue: "OPS-7", Seconds: 7200},
+			},
+		},
+		{
+			name:   "yesterday onto the target date",
+			spec:   "yesterday",
+			target: "2025-09-10",
+			want:   []TimeEntry{{Date: "2025-09-10", Issue: "PROJ-123", Seconds: 1800}},
+		},
+		{
+			name:          "with recurring worklogs",
+			spec:          "yesterday",
+			target:        "2025-09-10",
+			withRecurring: true,
+			want:          []TimeEntry{{Date: "2025-09-10", Issue: "PROJ-123", Seconds: 2700}},
+		},
+		{
+			name:   "a Friday onto a Monday",
+			spec:   "2025-09-12",
+			target: "2025-09-15",
+			want:   []TimeEntry{{Date: "2025-09-15", Issue: "OPS-7", Seconds: 7200}},
+		},
+		{
+			name:   "onto a day off",
+			spec:   "2025-09-12",
+			target: "2025-09-19",
+			want:   []TimeEntry{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CopyEntries(settings, NewLogger("error"), tt.spec, tt.target, tt.withRecurring)
+			if err != nil {
+				t.Fatalf("CopyEntries: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CopyEntries(%s onto %s)\n got %+v\nwant %+v", tt.spec, tt.target, got, tt.want)
+			}
+		})
+	}
+
+	for _, spec := range []string{"this-week", "2025-09-10", "last-month", "someday"} {
+		if _, err := CopyEntries(settings, NewLogger("error"), spec, "2025-09-10", false); err == nil {
+			t.Errorf("CopyEntries(%s) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestCopyEntriesFromLedger(t *testing.T) {
+	settings := newTestSettings(t, copyConfig, mockjira.Failure{Path: "/search/jql", Status: 503})
+	postTestWorklogs(t, settings, copySource[4:5])
+
+	// The hour of the fixture on Monday wasn't posted by this tool, only the ledger's Friday is copied
+	got, err := CopyEntries(settings, NewLogger("error"), "2025-W37", "2025-10-02", false)
+	if err != nil {
+		t.Fatalf("CopyEntries: %v", err)
+	}
+	want := []TimeEntry{{Date: "2025-10-03", Issue: "OPS-7", Seconds: 7200}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CopyEntries from the ledger\n got %+v\nwant %+v", got, want)
+	}
+
+	// Nothing in the ledger, the Jira error is returned
+	if _, err := CopyEntries(settings, NewLogger("error"), "2025-W36", "2025-10-02", false); err == nil {
+		t.Error("expected the Jira error without ledger worklogs")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateRange is an inclusive range of dates, at midnight UTC like the dates of ParseDate
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Days returns the dates of the range in YYYY-MM-DD format
+func (r DateRange) Days() []string {
+	days := []string{}
+	for day := r.From; !day.After(r.To); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+	}
+	return days
+}
+
+// String formats the range as FROM..TO, or a single date
+func (r DateRange) String() string {
+	if r.From.Equal(r.To) {
+		return r.From.Format("2006-01-02")
+	}
+	return r.From.Format("2006-01-02") + ".." + r.To.Format("2006-01-02")
+}
+
+// ParseDateRange parses a range of dates relative to today (YYYY-MM-DD):
+// today, yesterday, this-week, last-week, this-month, last-month, an ISO week (2025-W37),
+// a date (2025-09-08) or two dates (2025-09-01..2025-09-14)
+func ParseDateRange(spec, todayStr string) (DateRange, error) {
+	today, err := ParseDate(todayStr)
+	if err != nil {
+		return DateRange{}, err
+	}
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	firstOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	switch spec = strings.ToLower(strings.TrimSpace(spec)); spec {
+	case "today":
+		return DateRange{today, today}, nil
+	case "yesterday":
+		yesterday := today.AddDate(0, 0, -1)
+		return DateRange{yesterday, yesterday}, nil
+	case "this-week", "week":
+		return DateRange{monday, monday.AddDate(0, 0, 6)}, nil
+	case "last-week":
+		return DateRange{monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1)}, nil
+	case "this-month", "month":
+		return DateRange{firstOfMonth, firstOfMonth.AddDate(0, 1, -1)}, nil
+	case "last-month":
+		return DateRange{firstOfMonth.AddDate(0, -1, 0), firstOfMonth.AddDate(0, 0, -1)}, nil
+	}
+
+	if isoWeekPattern.MatchString(spec) {
+		weekMonday, err := ParseISOWeek(spec)
+		if err != nil {
+			return DateRange{}, err
+		}
+		return DateRange{weekMonday, weekMonday.AddDate(0, 0, 6)}, nil
+	}
+
+	fromStr, toStr, isRange := strings.Cut(spec, "..")
+	if !isRange {
+		toStr = fromStr
+	}
+	from, err := time.Parse("2006-01-02", fromStr)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("invalid date range %q, expected e.g. today, last-week, 2025-W37, 2025-09-08 or 2025-09-01..2025-09-14", spec)
+	}
+	to, err := time.Parse("2006-01-02", toStr)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("invalid date range %q, expected e.g. today, last-week, 2025-W37, 2025-09-08 or 2025-09-01..2025-09-14", spec)
+	}
+	if to.Before(from) {
+		return DateRange{}, fmt.Errorf("invalid date range %q, the end is before the start", spec)
+	}
+	return DateRange{from, to}, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DoctorResult is the outcome of the checks of the doctor command
+type DoctorResult struct {
+	OK     bool          `json:"ok" yaml:"ok"`
+	Checks []DoctorCheck `json:"checks" yaml:"checks"`
+}
+
+// DoctorCheck is one check of the setup
+type DoctorCheck struct {
+	Name   string `json:"name" yaml:"name"`
+	OK     bool   `json:"ok" yaml:"ok"`
+	Detail string `json:"detail" yaml:"detail"`
+}
+
+// runDoctor checks the configuration, the state directory and the connection to Jira, exiting
+// with the code of the first failed check
+func runDoctor(args []string) int {
+	outputFormat := OutputTable
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--output" && i+1 < len(args):
+			format, err := ParseOutputFormat(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+				return ExitConfig
+			}
+			outputFormat = format
+			i++
+		default:
+			fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger doctor [--output json|yaml|table]")
+			return ExitConfig
+		}
+	}
+
+	// Configuration errors exit with ExitConfig in setup
+	settings, logger := setup()
+	result := DoctorResult{OK: true}
+	exitCode := ExitOK
+	check := func(name string, err error, detail string, failCode int) {
+		if err != nil {
+			detail = err.Error()
+			if result.OK {
+				exitCode = failCode
+			}
+			result.OK = false
+		}
+		result.Checks = append(result.Checks, DoctorCheck{Name: name, OK: err == nil, Detail: detail})
+	}
+
+	check("config", nil, findConfigFile(), ExitConfig)
+
+	_, err := time.LoadLocation(settings.Timezone)
+	check("timezone", err, settings.Timezone, ExitConfig)
+
+	stateDir, err := StateDir()
+	if err == nil {
+		var probe *os.File
+		if probe, err = os.CreateTemp(stateDir, ".doctor-*"); err == nil {
+			probe.Close()
+			os.Remove(probe.Name())
+		}
+	}
+	check("state directory", err, stateDir, ExitFailure)
+
+	user, err := NewJiraClient(settings, logger).Myself(context.Background())
+	detail := ""
+	if err == nil {
+		detail = fmt.Sprintf("%s as %s (API v%s)", settings.JiraBaseURL, user.DisplayName, settings.APIVersion)
+	}
+	check("jira", err, detail, ExitCodeFor(err))
+
+	if outputFormat != OutputTable {
+		if err := WriteOutput(outputFormat, result); err != nil {
+			logger.Error("Failed to write the result: %v", err)
+			return ExitFailure
+		}
+		return exitCode
+	}
+
+	for _, c := range result.Checks {
+		status := "ok"
+		if !c.OK {
+			status = "FAIL"
+		}
+		fmt.Printf("  %-4s  %-16s %s\n", status, c.Name, c.Detail)
+	}
+	if !result.OK {
+		fmt.Println("Some checks failed.")
+	}
+	return exitCode
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// errorMarkerPrefix starts the inline error markers added when a saved file fails to parse
+const errorMarkerPrefix = "# ERROR: "
+
+// EditorCommand returns the user's preferred editor from $VISUAL or $EDITOR
+func EditorCommand() string {
+	if editor := strings.TrimSpace(os.Getenv("VISUAL")); editor != "" {
+		return editor
+	}
+	return strings.TrimSpace(os.Getenv("EDITOR"))
+}
+
+// EditText writes the initial text to a temp file, opens it in the user's editor
+// and returns the saved contents
+func EditText(initial string) (string, error) {
+	editor := EditorCommand()
+	if editor == "" {
+		return "", fmt.Errorf("no editor configured, set $VISUAL or $EDITOR")
+	}
+
+	tmpFile, err := os.CreateTemp("", "jira-worklogger-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(initial); err != nil {
+		tmpFile.Close()
+		return "", fmt.Errorf("failed to write temp file: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temp file: %v", err)
+	}
+
+	// The editor may include arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], tmpFile.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %v", editor, err)
+	}
+
+	data, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read temp file: %v", err)
+	}
+	return string(data), nil
+}
+
+// BuildEntriesTemplate builds the editor template for a date, listing the aliases,
+// the suggested epics and the worklogs already logged as comment lines
+func BuildEntriesTemplate(dateStr string, aliases map[string]string, epics map[string]Epic, existing []ExistingWorklog) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Jira Worklogger entries for %s\n", dateStr)
+	b.WriteString("# One entry per line: alias=duration or ISSUE-123=duration (e.g. meetings=1h, PROJ-123=1h30m)\n")
+	b.WriteString("# Optionally follow the duration with @09:30, [role:NAME] or [group:NAME], and a \"quoted comment\".\n")
+	b.WriteString("# Start a line with a date header (e.g. \"tue:\" or \"2025-09-08:\") to log against another day.\n")
+	b.WriteString("# Lines starting with # are ignored. Save an empty file to abort.\n")
+
+	if len(aliases) > 0 {
+		b.WriteString("#\n# Aliases:\n")
+		keys := make([]string, 0, len(aliases))
+		for k := range aliases {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "#   %-10s -> %s\n", k, aliases[k])
+		}
+	}
+
+	if len(epics) > 0 {
+		b.WriteString("#\n# Suggested epics:\n")
+		keys := make([]string, 0, len(epics))
+		for k := range epics {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "#   %s: %s\n", k, epics[k].Summary)
+		}
+	}
+
+	if len(existing) > 0 {
+		total := 0
+		for _, w := range existing {
+			total += w.Seconds
+		}
+		fmt.Fprintf(&b, "#\n# Already logged on %s (%s):\n", dateStr, FormatSeconds(total))
+		for _, w := range existing {
+			fmt.Fprintf(&b, "#   %-12s %-8s %s\n", w.Issue, FormatSeconds(w.Seconds), w.Summary)
+		}
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+// AnnotateEntryError removes earlier error markers from the text and adds a marker for err,
+// above the offending line when it is known, otherwise at the top
+func AnnotateEntryError(text string, err error) string {
+	lines := strings.Split(text, "\n")
+
+	errorLine := 0
+	var entryErr *EntryError
+	if errors.As(err, &entryErr) {
+		errorLine = entryErr.Line
+	}
+
+	annotated := []string{}
+	if errorLine == 0 {
+		annotated = append(annotated, errorMarkerPrefix+err.Error())
+	}
+	for i, line := range lines {
+		if i+1 == errorLine {
+			annotated = append(annotated, errorMarkerPrefix+err.Error())
+		}
+		if strings.HasPrefix(line, errorMarkerPrefix) {
+			continue
+		}
+		annotated = append(annotated, line)
+	}
+
+	return strings.Join(annotated, "\n")
+}
+
+// EditEntries opens the template in the user's editor and validates the saved result with parse,
+// re-opening the file with an inline error marker until it parses
+func EditEntries(template string, parse func(string) error) (string, error) {
+	text := template
+	for {
+		edited, err := EditText(text)
+		if err != nil {
+			return "", err
+		}
+
+		parseErr := parse(edited)
+		if parseErr == nil {
+			return edited, nil
+		}
+
+		fmt.Fprintf(os.Stderr, "[error] %v, re-opening editor\n", parseErr)
+		text = AnnotateEntryError(edited, parseErr)
+	}
+}
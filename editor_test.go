@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateEntryError(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		err  error
+		want string
+	}{
+		{
+			name: "above the offending line",
+			text: "meetings=1h\nPROJ-1=lots\n",
+			err:  &EntryError{Line: 2, Entry: "PROJ-1=lots", Err: errors.New("bad time")},
+			want: "meetings=1h\n# ERROR: bad time\nPROJ-1=lots\n",
+		},
+		{
+			name: "earlier markers are removed",
+			text: "# ERROR: old\nmeetings=1h\n# ERROR: older\nPROJ-1=lots",
+			err:  &EntryError{Line: 4, Entry: "PROJ-1=lots", Err: errors.New("bad time")},
+			want: "meetings=1h\n# ERROR: bad time\nPROJ-1=lots",
+		},
+		{
+			name: "at the top without a line",
+			text: "# ERROR: old\nmeetings=1h",
+			err:  errors.New("invalid date"),
+			want: "# ERROR: invalid date\nmeetings=1h",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnnotateEntryError(tt.text, tt.err); got != tt.want {
+				t.Errorf("AnnotateEntryError\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditEntries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake editor is a shell script")
+	}
+
+	// The fake editor saves an invalid entry first, then fixes it and drops the error marker
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "editor.sh")
+	script := `#!/bin/sh
+n=$(cat "$EDIT_DIR/count" 2>/dev/null || echo 0)
+n=$((n + 1))
+echo $n > "$EDIT_DIR/count"
+cp "$1" "$EDIT_DIR/seen.$n"
+if [ $n = 1 ]; then
+	printf 'meetings=1h\nPROJ-1=lots\n' > "$1"
+else
+	sed -e '/^# ERROR/d' -e 's/lots/2h/' "$1" > "$1.new" && mv "$1.new" "$1"
+fi
+`
+	if err := os.WriteFile(editor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+	t.Setenv("EDIT_DIR", dir)
+
+	parse := func(text string) error {
+		_, err := ParseTimeEntries(text, "2025-09-10", map[string]string{"meetings": "PROJ-123"}, NewLogger("error"))
+		return err
+	}
+	got, err := EditEntries("# template\n", parse)
+	if err != nil {
+		t.Fatalf("EditEntries: %v", err)
+	}
+	if got != "meetings=1h\nPROJ-1=2h\n" {
+		t.Errorf("EditEntries returned %q", got)
+	}
+
+	first, _ := os.ReadFile(filepath.Join(dir, "seen.1"))
+	if string(first) != "# template\n" {
+		t.Errorf("the editor was first opened with %q, want the template", first)
+	}
+	second, _ := os.ReadFile(filepath.Join(dir, "seen.2"))
+	if lines := strings.Split(string(second), "\n"); len(lines) < 3 || !strings.HasPrefix(lines[1], errorMarkerPrefix) || lines[2] != "PROJ-1=lots" {
+		t.Errorf("expected the editor to be re-opened with a marker above line 2, got %q", second)
+	}
+}
+
+func TestBuildEntriesTemplate(t *testing.T) {
+	template := BuildEntriesTemplate("2025-09-08",
+		map[string]string{"support": "PROJ-456", "meetings": "PROJ-123"},
+		map[string]Epic{"PROJ-100": {Key: "PROJ-100", Summary: "Platform improvements"}},
+		[]ExistingWorklog{{Issue: "PROJ-124", Summary: "Code review", Seconds: 3600}, {Issue: "OPS-7", Seconds: 1800}})
+
+	for _, want := range []string{
+		"# Jira Worklogger entries for 2025-09-08\n",
+		"#   meetings   -> PROJ-123\n#   support    -> PROJ-456\n",
+		"#   PROJ-100: Platform improvements\n",
+		"# Already logged on 2025-09-08 (1h30m):\n#   PROJ-124     1h0m     Code review\n",
+	} {
+		if !strings.Contains(template, want) {
+			t.Errorf("template is missing %q:\n%s", want, template)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(template), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			t.Errorf("template line %q is not a comment", line)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+)
+
+// Exit codes, so scripts can tell failures apart
+const (
+	ExitOK          = 0
+	ExitFailure     = 1 // Nothing could be posted, or an error that fits no other code
+	ExitConfig      = 2 // Missing or invalid configuration, or invalid command line usage
+	ExitAuth        = 3 // Jira rejected the credentials or the permissions
+	ExitValidation  = 4 // Invalid time entries, or target issues that can't be logged to
+	ExitPartial     = 5 // Some worklogs were posted, others failed
+	ExitNetwork     = 6 // Jira could not be reached
+	ExitMissingTime = 7 // The check command found working days below the daily target
+)
+
+// ExitCodeFor returns the exit code for an error returned by a Jira request
+func ExitCodeFor(err error) int {
+	var requestErr *jira.RequestError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, jira.ErrUnauthorized), errors.Is(err, jira.ErrForbidden):
+		return ExitAuth
+	case errors.As(err, &requestErr):
+		return ExitNetwork
+	}
+	return ExitFailure
+}
+
+// ExitCodeForFailures returns the exit code of a run that posted some worklogs and failed to
+// post others. When nothing was posted and every failure has the same kind, the code tells it.
+func ExitCodeForFailures(posted int, failures []FailedEntry) int {
+	if len(failures) == 0 {
+		return ExitOK
+	}
+	if posted > 0 {
+		return ExitPartial
+	}
+
+	kind := failures[0].Kind
+	for _, failed := range failures[1:] {
+		if failed.Kind != kind {
+			return ExitFailure
+		}
+	}
+	switch kind {
+	case FailureAuth:
+		return ExitAuth
+	case FailureValidation:
+		return ExitValidation
+	case FailureNetwork:
+		return ExitNetwork
+	}
+	return ExitFailure
+}
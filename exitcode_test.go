@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/liam-witterick/jira-worklogger/mockjira"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		failure *mockjira.Failure
+		baseURL string
+		want    int
+	}{
+		{name: "ok", want: ExitOK},
+		{name: "unauthorized", failure: &mockjira.Failure{Path: "/myself", Status: 401}, want: ExitAuth},
+		{name: "forbidden", failure: &mockjira.Failure{Path: "/myself", Status: 403}, want: ExitAuth},
+		{name: "server error", failure: &mockjira.Failure{Path: "/myself", Status: 500}, want: ExitFailure},
+		{name: "unreachable", baseURL: "http://127.0.0.1:1", want: ExitNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := []mockjira.Failure{}
+			if tt.failure != nil {
+				failures = append(failures, *tt.failure)
+			}
+			settings := newTestSettings(t, "", failures...)
+			if tt.baseURL != "" {
+				settings.JiraBaseURL = tt.baseURL
+			}
+			_, err := NewJiraClient(settings, NewLogger("error")).Myself(context.Background())
+			if got := ExitCodeFor(err); got != tt.want {
+				t.Errorf("ExitCodeFor(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+
+	if got := ExitCodeFor(errors.New("no config file found")); got != ExitFailure {
+		t.Errorf("ExitCodeFor() of other errors = %d, want %d", got, ExitFailure)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gridDayLabels are the column headings of a week grid, Monday first
+var gridDayLabels = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// isoWeekPattern matches an ISO week such as "2025-W37"
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-?[Ww](\d{1,2})$`)
+
+// ParseISOWeek parses an ISO week string (YYYY-Www) and returns the Monday of that week
+func ParseISOWeek(weekStr string) (time.Time, error) {
+	matches := isoWeekPattern.FindStringSubmatch(strings.TrimSpace(weekStr))
+	if matches == nil {
+		return time.Time{}, fmt.Errorf("invalid week %q, expected format YYYY-Www (e.g. 2025-W37)", weekStr)
+	}
+	year, _ := strconv.Atoi(matches[1])
+	week, _ := strconv.Atoi(matches[2])
+
+	// January 4th is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+
+	if y, w := monday.ISOWeek(); week < 1 || y != year || w != week {
+		return time.Time{}, fmt.Errorf("week %d does not exist in %d", week, year)
+	}
+	return monday, nil
+}
+
+// stripGridComment removes a trailing "# ..." comment from a grid row
+func stripGridComment(row string) string {
+	if strings.HasPrefix(strings.TrimSpace(row), "#") {
+		return ""
+	}
+	if i := strings.Index(row, " #"); i >= 0 {
+		row = row[:i]
+	}
+	return strings.TrimSpace(row)
+}
+
+// ParseWeekGrid parses week grid rows such as "meetings  1h 1h 0 2h 1h" into dated time entries.
+// Each row starts with an alias or issue key followed by one duration per day, Monday first.
+// Missing trailing columns count as zero and "-" can be used for an empty cell.
+func ParseWeekGrid(gridStr string, monday time.Time, aliases map[string]string, logger *Logger) ([]TimeEntry, error) {
+	entries := []TimeEntry{}
+
+	rows := []string{}
+	for _, line := range strings.Split(gridStr, "\n") {
+		for _, part := range strings.Split(line, ";") {
+			if part = stripGridComment(part); part != "" {
+				rows = append(rows, part)
+			}
+		}
+	}
+
+	for _, row := range rows {
+		fields := strings.Fields(row)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("grid row %q has no durations", row)
+		}
+		if len(fields)-1 > len(gridDayLabels) {
+			return nil, fmt.Errorf("grid row %q has %d columns, at most %d are allowed", row, len(fields)-1, len(gridDayLabels))
+		}
+
+		issue := strings.TrimSuffix(fields[0], ":")
+		alias := ""
+		if aliasValue, ok := aliases[strings.ToLower(issue)]; ok {
+			logger.Info("Using alias '%s' -> %s", issue, aliasValue)
+			alias = issue
+			issue = aliasValue
+		}
+
+		for i, cell := range fields[1:] {
+			if cell == "-" {
+				continue
+			}
+			seconds, err := ToTimeSpentSeconds(cell)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s for %s in grid row %q: %v", gridDayLabels[i], fields[0], row, err)
+			}
+			if seconds > 0 {
+				entries = append(entries, TimeEntry{
+					Date:    monday.AddDate(0, 0, i).Format("2006-01-02"),
+					Issue:   issue,
+					Alias:   alias,
+					Seconds: seconds,
+				})
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// BuildWeekGridTemplate builds a pre-filled Mon-Fri grid with aliases and suggested epics as row labels
+func BuildWeekGridTemplate(weekStr string, monday time.Time, aliases map[string]string, epics map[string]Epic) string {
+	var b strings.Builder
+	friday := monday.AddDate(0, 0, 4)
+
+	fmt.Fprintf(&b, "# Week %s (%s .. %s)\n", weekStr, monday.Format("2006-01-02"), friday.Format("2006-01-02"))
+	b.WriteString("# Fill in one duration per day (1h, 30m, 1:30, 0 or -). Add Sat/Sun columns if needed.\n")
+	b.WriteString("# Rows left at zero are ignored. Lines starting with # are ignored.\n")
+	fmt.Fprintf(&b, "#%-19s %s\n", "", strings.Join(gridDayLabels[:5], "   "))
+
+	aliasKeys := make([]string, 0, len(aliases))
+	for k := range aliases {
+		aliasKeys = append(aliasKeys, k)
+	}
+	sort.Strings(aliasKeys)
+	for _, k := range aliasKeys {
+		fmt.Fprintf(&b, "%-20s 0     0     0     0     0      # %s\n", k, aliases[k])
+	}
+
+	epicKeys := make([]string, 0, len(epics))
+	for k := range epics {
+		epicKeys = append(epicKeys, k)
+	}
+	sort.Strings(epicKeys)
+	for _, k := range epicKeys {
+		fmt.Fprintf(&b, "%-20s 0     0     0     0     0      # %s\n", k, epics[k].Summary)
+	}
+
+	return b.String()
+}
+
+// FormatSeconds formats a number of seconds as hours and minutes (e.g. 7h30m)
+func FormatSeconds(seconds int) string {
+	return fmt.Sprintf("%dh%dm", seconds/3600, (seconds%3600)/60)
+}
+
+// WeekColumnTotals returns one line per day of the week with the total time logged, plus a grand total
+func WeekColumnTotals(monday time.Time, entries []TimeEntry) []string {
+	totals := map[string]int{}
+	grandTotal := 0
+	for _, entry := range entries {
+		totals[entry.Date] += entry.Seconds
+		grandTotal += entry.Seconds
+	}
+
+	lines := []string{}
+	for i, label := range gridDayLabels {
+		date := monday.AddDate(0, 0, i).Format("2006-01-02")
+		// Only show weekends when time was logged on them
+		if i >= 5 && totals[date] == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %s  %s", label, date, FormatSeconds(totals[date])))
+	}
+	lines = append(lines, fmt.Sprintf("Total           %s", FormatSeconds(grandTotal)))
+
+	return lines
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWeekGrid(t *testing.T) {
+	monday, err := ParseISOWeek("2025-W37")
+	if err != nil || monday.Format("2006-01-02") != "2025-09-08" {
+		t.Fatalf("ParseISOWeek(2025-W37) = %v, %v, want 2025-09-08", monday, err)
+	}
+
+	entries, err := ParseWeekGrid("meetings 1h - 0 30m # standups\nPROJ-124: 2h 2h 2h 2h 2h 1h", monday, map[string]string{"meetings": "PROJ-123"}, NewLogger("error"))
+	if err != nil {
+		t.Fatalf("ParseWeekGrid: %v", err)
+	}
+	want := []TimeEntry{
+		{Date: "2025-09-08", Issue: "PROJ-123", Alias: "meetings", Seconds: 3600},
+		{Date: "2025-09-11", Issue: "PROJ-123", Alias: "meetings", Seconds: 1800},
+		{Date: "2025-09-08", Issue: "PROJ-124", Seconds: 7200},
+		{Date: "2025-09-09", Issue: "PROJ-124", Seconds: 7200},
+		{Date: "2025-09-10", Issue: "PROJ-124", Seconds: 7200},
+		{Date: "2025-09-11", Issue: "PROJ-124", Seconds: 7200},
+		{Date: "2025-09-12", Issue: "PROJ-124", Seconds: 7200},
+		{Date: "2025-09-13", Issue: "PROJ-124", Seconds: 3600},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParseWeekGrid\n got %+v\nwant %+v", entries, want)
+	}
+
+	totals := WeekColumnTotals(monday, entries)
+	wantTotals := []string{
+		"Mon 2025-09-08  3h0m",
+		"Tue 2025-09-09  2h0m",
+		"Wed 2025-09-10  2h0m",
+		"Thu 2025-09-11  2h30m",
+		"Fri 2025-09-12  2h0m",
+		"Sat 2025-09-13  1h0m",
+		"Total           12h30m",
+	}
+	if !reflect.DeepEqual(totals, wantTotals) {
+		t.Errorf("WeekColumnTotals\n got %q\nwant %q", totals, wantTotals)
+	}
+
+	for _, grid := range []string{"meetings", "meetings 1h 1h 1h 1h 1h 1h 1h 1h", "meetings 1h lots"} {
+		if _, err := ParseWeekGrid(grid, monday, nil, NewLogger("error")); err == nil {
+			t.Errorf("ParseWeekGrid(%q) succeeded, want an error", grid)
+		}
+	}
+}
+
+func TestWeekColumnTotalsWithRecurring(t *testing.T) {
+	settings := newTestSettings(t, `
+recurring:
+  - name: standup
+    alias: PROJ-123
+    duration: 15m
+    days: [mon, tue]
+`)
+	monday, _ := ParseISOWeek("2025-W37")
+	entries, err := ParseWeekGrid("PROJ-124 2h 2h 2h", monday, nil, NewLogger("error"))
+	if err != nil {
+		t.Fatalf("ParseWeekGrid: %v", err)
+	}
+
+	// The totals shown before posting count the recurring entries too
+	totals := WeekColumnTotals(monday, ApplyRecurring(settings, NewLogger("error"), entries))
+	want := []string{
+		"Mon 2025-09-08  2h15m",
+		"Tue 2025-09-09  2h15m",
+		"Wed 2025-09-10  2h0m",
+		"Thu 2025-09-11  0h0m",
+		"Fri 2025-09-12  0h0m",
+		"Total           6h30m",
+	}
+	if !reflect.DeepEqual(totals, want) {
+		t.Errorf("WeekColumnTotals\n got %q\nwant %q", totals, want)
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpTrace records the Jira traffic when --trace-http is given, nil otherwise
+var httpTrace *HARRecorder
+
+// sensitiveHeaders are replaced in traces so they can be attached to support tickets
+var sensitiveHeaders = map[string]bool{
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+// SetupHTTPTrace removes "--trace-http FILE" from the arguments and starts recording the Jira
+// traffic to FILE. The option is accepted anywhere, including after subcommands.
+func SetupHTTPTrace(args []string) ([]string, error) {
+	remaining := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--trace-http" {
+			remaining = append(remaining, args[i])
+			continue
+		}
+		if i+1 >= len(args) || strings.HasPrefix(args[i+1], "--") {
+			return nil, fmt.Errorf("no file provided for --trace-http")
+		}
+		recorder, err := NewHARRecorder(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		httpTrace = recorder
+		i++
+	}
+	return remaining, nil
+}
+
+// HARRecorder is an http.RoundTripper that records requests and responses to a HAR 1.2 file,
+// which loads in browser devtools. The file is rewritten after every request, so it is complete
+// however the program exits.
+type HARRecorder struct {
+	mu     sync.Mutex
+	path   string
+	next   http.RoundTripper
+	har    harFile
+	warned bool
+}
+
+// NewHARRecorder creates the HAR file at path and returns a recorder writing to it
+func NewHARRecorder(path string) (*HARRecorder, error) {
+	r := &HARRecorder{
+		path: path,
+		next: http.DefaultTransport,
+		har: harFile{Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: "jira-worklogger", Version: Version},
+			Entries: []harEntry{},
+		}},
+	}
+	if err := r.save(); err != nil {
+		return nil, fmt.Errorf("error creating HTTP trace: %v", err)
+	}
+	return r, nil
+}
+
+// Client returns an HTTP client whose requests are recorded
+func (r *HARRecorder) Client() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second, Transport: r}
+}
+
+// RoundTrip sends the request and records it with its response and timings
+func (r *HARRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	timings := &harTimer{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timings.clientTrace()))
+	timings.start = time.Now()
+	resp, err := r.next.RoundTrip(req)
+
+	entry := harEntry{
+		StartedDateTime: timings.start.Format(time.RFC3339Nano),
+		Request:         harRequestFor(req, requestBody),
+		Cache:           struct{}{},
+	}
+	if err != nil {
+		// No response, recorded the way browsers record failed requests
+		entry.Response = harResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			Content:     harContent{MimeType: "x-unknown"},
+			HeadersSize: -1,
+			BodySize:    -1,
+			Error:       err.Error(),
+		}
+		entry.Timings, entry.Time = timings.result(time.Now())
+		r.record(entry)
+		return nil, err
+	}
+
+	responseBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+	entry.Response = harResponseFor(resp, responseBody)
+	if readErr != nil {
+		entry.Response.Error = readErr.Error()
+	}
+	entry.Timings, entry.Time = timings.result(time.Now())
+	r.record(entry)
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	return resp, nil
+}
+
+// record adds an entry and rewrites the file, warning once if it can't be written
+func (r *HARRecorder) record(entry harEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.har.Log.Entries = append(r.har.Log.Entries, entry)
+	if err := r.save(); err != nil && !r.warned {
+		r.warned = true
+		fmt.Fprintf(logOutput, "[warn] Failed to write HTTP trace to %s: %v\n", r.path, err)
+	}
+}
+
+// save writes the HAR file, redacting the secrets known to the logger
+func (r *HARRecorder) save() error {
+	data, err := json.MarshalIndent(r.har, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, []byte(logSinks.redact.Redact(string(data))), 0600)
+}
+
+// harTimer collects the phases of a request from an httptrace.ClientTrace
+type harTimer struct {
+	mu                                sync.Mutex
+	start                             time.Time
+	dnsStart, dnsDone                 time.Time
+	connectStart, connectDone         time.Time
+	tlsStart, tlsDone                 time.Time
+	gotConn, wroteRequest, firstBytes time.Time
+}
+
+func (t *harTimer) clientTrace() *httptrace.ClientTrace {
+	mark := func(at *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if at.IsZero() {
+			*at = time.Now()
+		}
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { mark(&t.dnsDone) },
+		ConnectStart:         func(string, string) { mark(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { mark(&t.connectDone) },
+		TLSHandshakeStart:    func() { mark(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { mark(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { mark(&t.gotConn) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() { mark(&t.firstBytes) },
+	}
+}
+
+// result converts the collected times to HAR timings in milliseconds, -1 for phases that did
+// not happen, and returns them with the total time
+func (t *harTimer) result(end time.Time) (harTimings, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return float64(to.Sub(from).Microseconds()) / 1000
+	}
+	// HAR counts connect time including the TLS handshake
+	connectEnd := t.connectDone
+	if !t.tlsDone.IsZero() {
+		connectEnd = t.tlsDone
+	}
+	blockedEnd := t.dnsStart
+	if blockedEnd.IsZero() {
+		blockedEnd = t.connectStart
+	}
+	if blockedEnd.IsZero() {
+		blockedEnd = t.gotConn
+	}
+
+	timings := harTimings{
+		Blocked: span(t.start, blockedEnd),
+		DNS:     span(t.dnsStart, t.dnsDone),
+		Connect: span(t.connectStart, connectEnd),
+		SSL:     span(t.tlsStart, t.tlsDone),
+		Send:    max(span(t.gotConn, t.wroteRequest), 0),
+		Wait:    max(span(t.wroteRequest, t.firstBytes), 0),
+		Receive: max(span(t.firstBytes, end), 0),
+	}
+	return timings, float64(end.Sub(t.start).Microseconds()) / 1000
+}
+
+// harRequestFor converts a request for the HAR file
+func harRequestFor(req *http.Request, body []byte) harRequest {
+	request := harRequest{
+		Method:      req.Method,
+		URL:         redactedURL(req),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			request.QueryString = append(request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.Slice(request.QueryString, func(i, j int) bool {
+		return request.QueryString[i].Name < request.QueryString[j].Name
+	})
+	if body != nil {
+		request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+	}
+	return request
+}
+
+// harResponseFor converts a response for the HAR file
+func harResponseFor(resp *http.Response, body []byte) harResponse {
+	mimeType := resp.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = "x-unknown"
+	}
+	response := harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(resp.Header),
+		Content:     harContent{Size: len(body), MimeType: mimeType},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if isTextMimeType(mimeType) {
+		response.Content.Text = string(body)
+	}
+	return response
+}
+
+// harHeaders converts headers for the HAR file, sorted by name, hiding credentials
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			if sensitiveHeaders[strings.ToLower(name)] {
+				value = redactedText
+			}
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+	return headers
+}
+
+// redactedURL returns the URL of a request without a password in it
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = nil
+	}
+	return u.String()
+}
+
+// isTextMimeType reports whether a body can be stored as text
+func isTextMimeType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") || mediaType == "application/x-www-form-urlencoded"
+}
+
+// The HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	Error       string         `json:"_error,omitempty"` // Custom field, as browsers record failed requests
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
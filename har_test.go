@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readHAR decodes a HAR file
+func readHAR(t *testing.T, path string) harFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("invalid HAR file: %v", err)
+	}
+	return har
+}
+
+// harHeader returns the value of a header of a HAR entry
+func harHeader(headers []harNameValue, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+func TestHARRecorder(t *testing.T) {
+	const token = "ATATT3xFfGF0s3cr3t"
+	captureLogs(t, &Settings{JiraEmailOrUser: "jane.doe@example.com", JiraAPIToken: token})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "abc123"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "42")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"10001"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "trace.har")
+	recorder, err := NewHARRecorder(path)
+	if err != nil {
+		t.Fatalf("NewHARRecorder: %v", err)
+	}
+	if har := readHAR(t, path); har.Log.Version != "1.2" || len(har.Log.Entries) != 0 {
+		t.Fatalf("expected an empty HAR 1.2 file, got %+v", har.Log)
+	}
+
+	url := strings.Replace(server.URL, "http://", "http://jane:hunter2@", 1) + "/rest/api/3/issue/PROJ-1/worklog?notifyUsers=false"
+	req, _ := http.NewRequest("POST", url, strings.NewReader(`{"comment":"token `+token+`"}`))
+	req.SetBasicAuth("jane.doe@example.com", token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", "JSESSIONID=abc123")
+	resp, err := recorder.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+
+	// A request without a response is recorded with its error
+	req, _ = http.NewRequest("GET", "http://127.0.0.1:1/rest/api/3/myself", nil)
+	if _, err := recorder.Client().Do(req); err == nil {
+		t.Fatal("expected a connection error")
+	}
+
+	har := readHAR(t, path)
+	if len(har.Log.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(har.Log.Entries))
+	}
+	entry := har.Log.Entries[0]
+	request, response := entry.Request, entry.Response
+
+	for name, value := range map[string]string{
+		"request Authorization": harHeader(request.Headers, "Authorization"),
+		"request Cookie":        harHeader(request.Headers, "Cookie"),
+		"response Set-Cookie":   harHeader(response.Headers, "Set-Cookie"),
+	} {
+		if value != redactedText {
+			t.Errorf("%s = %q, want it redacted", name, value)
+		}
+	}
+	if harHeader(response.Headers, "X-Request-Id") != "42" {
+		t.Errorf("expected other headers to be kept, got %+v", response.Headers)
+	}
+	if strings.Contains(request.URL, "hunter2") || !strings.HasSuffix(request.URL, "/rest/api/3/issue/PROJ-1/worklog?notifyUsers=false") {
+		t.Errorf("unexpected URL %s", request.URL)
+	}
+	if !reflect.DeepEqual(request.QueryString, []harNameValue{{Name: "notifyUsers", Value: "false"}}) {
+		t.Errorf("unexpected query string %+v", request.QueryString)
+	}
+	if request.PostData == nil || request.PostData.Text != `{"comment":"token [REDACTED]"}` {
+		t.Errorf("expected the token to be redacted from the body, got %+v", request.PostData)
+	}
+	if response.Status != 201 || response.Content.Text != `{"id":"10001"}` || response.Content.MimeType != "application/json" {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), token) || strings.Contains(string(data), "abc123") {
+		t.Errorf("HAR file leaks a secret:\n%s", data)
+	}
+
+	if failed := har.Log.Entries[1].Response; failed.Error == "" || failed.Status != 0 {
+		t.Errorf("expected the connection error to be recorded, got %+v", failed)
+	}
+}
+
+func TestSetupHTTPTrace(t *testing.T) {
+	t.Cleanup(func() { httpTrace = nil })
+	path := filepath.Join(t.TempDir(), "trace.har")
+
+	args, err := SetupHTTPTrace([]string{"post", "--trace-http", path, "--yes"})
+	if err != nil {
+		t.Fatalf("SetupHTTPTrace: %v", err)
+	}
+	if !reflect.DeepEqual(args, []string{"post", "--yes"}) || httpTrace == nil {
+		t.Errorf("SetupHTTPTrace left %q, recorder %v", args, httpTrace)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected the trace file to be created: %v", err)
+	}
+
+	for _, args := range [][]string{{"--trace-http"}, {"--trace-http", "--yes"}} {
+		if _, err := SetupHTTPTrace(args); err == nil {
+			t.Errorf("SetupHTTPTrace(%q) succeeded, want an error", args)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package holidays reads public holidays from iCalendar (.ics) files, and bundles the holidays
+// of a few countries for the years around the release.
+package holidays
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Holiday is a day off, named after the calendar event
+type Holiday struct {
+	Date string // YYYY-MM-DD
+	Name string
+}
+
+//go:embed data/*.ics
+var bundled embed.FS
+
+// Countries returns the codes of the bundled countries, e.g. GB
+func Countries() []string {
+	entries, _ := bundled.ReadDir("data")
+	codes := []string{}
+	for _, entry := range entries {
+		codes = append(codes, strings.TrimSuffix(entry.Name(), ".ics"))
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Country returns the bundled holidays of a country, by ISO 3166 code
+func Country(code string) ([]Holiday, error) {
+	file, err := bundled.Open(path.Join("data", strings.ToUpper(code)+".ics"))
+	if err != nil {
+		return nil, fmt.Errorf("no bundled holidays for country %q, available: %s", code, strings.Join(Countries(), ", "))
+	}
+	defer file.Close()
+	return ParseICS(file)
+}
+
+// LoadICS reads the holidays of an iCalendar file
+func LoadICS(filename string) ([]Holiday, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	holidays, err := ParseICS(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return holidays, nil
+}
+
+// ParseICS reads the events of an iCalendar stream as holidays, one per day they cover. Events
+// spanning several days, as exported by most calendar apps, give one holiday per day. Recurrence
+// rules are not expanded, which suits the yearly holiday calendars published with one event
+// per occurrence.
+func ParseICS(r io.Reader) ([]Holiday, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := []Holiday{}
+	var start, end, summary string
+	inEvent := false
+	for i, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// Drop parameters such as ;VALUE=DATE or ;TZID=Europe/London
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent = true
+			start, end, summary = "", "", ""
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			inEvent = false
+			days, err := eventDays(start, end)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			for _, day := range days {
+				holidays = append(holidays, Holiday{Date: day, Name: summary})
+			}
+		case !inEvent:
+		case name == "DTSTART":
+			start = value
+		case name == "DTEND":
+			end = value
+		case name == "SUMMARY":
+			summary = unescapeText(value)
+		}
+	}
+
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	return holidays, nil
+}
+
+// unfoldLines reads the content lines of an iCalendar stream, joining folded lines, which
+// continue on the next line after a space or a tab
+func unfoldLines(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// eventDays returns the days covered by an event. DTEND is exclusive; without it the event lasts
+// one day.
+func eventDays(start, end string) ([]string, error) {
+	if start == "" {
+		return nil, fmt.Errorf("event without DTSTART")
+	}
+	from, err := parseICSDate(start)
+	if err != nil {
+		return nil, err
+	}
+	to := from.AddDate(0, 0, 1)
+	if end != "" {
+		if to, err = parseICSDate(end); err != nil {
+			return nil, err
+		}
+		// A timed event ending after midnight still covers its last day
+		if _, clock, timed := strings.Cut(end, "T"); timed && !strings.HasPrefix(clock, "000000") {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+
+	days := []string{}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+	}
+	if len(days) == 0 {
+		days = append(days, from.Format("2006-01-02"))
+	}
+	return days, nil
+}
+
+// parseICSDate parses the date of a DATE (20251225) or DATE-TIME (20251225T090000Z) value
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < len("20060102") {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	date, err := time.Parse("20060102", value[:len("20060102")])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+// unescapeText decodes the escapes of an iCalendar TEXT value
+func unescapeText(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
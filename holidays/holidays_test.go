@@ -0,0 +1,110 @@
+package holidays
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"SUMMARY:Not an event",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20251226",
+		"DTEND;VALUE=DATE:20251227",
+		"SUMMARY:Boxing Day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20251224",
+		"DTEND;VALUE=DATE:20251226",
+		"SUMMARY:Christmas Eve\\, and Christmas Day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20260101",
+		"SUMMARY:New Year's",
+		"  Day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=Europe/London:20260102T090000",
+		"DTEND;TZID=Europe/London:20260103T120000",
+		"SUMMARY:Offsite",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20260105T090000Z",
+		"DTEND:20260105T170000Z",
+		"SUMMARY:Training",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	got, err := ParseICS(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	want := []Holiday{
+		{Date: "2025-12-24", Name: "Christmas Eve, and Christmas Day"},
+		{Date: "2025-12-25", Name: "Christmas Eve, and Christmas Day"},
+		{Date: "2025-12-26", Name: "Boxing Day"},
+		{Date: "2026-01-01", Name: "New Year's Day"},
+		{Date: "2026-01-02", Name: "Offsite"},
+		{Date: "2026-01-03", Name: "Offsite"},
+		{Date: "2026-01-05", Name: "Training"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseICS\n got %+v\nwant %+v", got, want)
+	}
+
+	for _, event := range []string{
+		"BEGIN:VEVENT\nSUMMARY:No start\nEND:VEVENT",
+		"BEGIN:VEVENT\nDTSTART:2025\nEND:VEVENT",
+		"BEGIN:VEVENT\nDTSTART:20251301\nEND:VEVENT",
+	} {
+		if _, err := ParseICS(strings.NewReader(event)); err == nil {
+			t.Errorf("ParseICS(%q) succeeded, want an error", event)
+		}
+	}
+}
+
+func TestLoadICS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "company.ics")
+	if err := os.WriteFile(path, []byte("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20251224\nSUMMARY:Company day\nEND:VEVENT\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadICS(path)
+	if err != nil || !reflect.DeepEqual(got, []Holiday{{Date: "2025-12-24", Name: "Company day"}}) {
+		t.Errorf("LoadICS = %+v, %v", got, err)
+	}
+	if _, err := LoadICS(filepath.Join(t.TempDir(), "missing.ics")); err == nil {
+		t.Error("LoadICS of a missing file succeeded")
+	}
+}
+
+func TestCountry(t *testing.T) {
+	if got := Countries(); !reflect.DeepEqual(got, []string{"DE", "FR", "GB", "US"}) {
+		t.Errorf("Countries() = %v", got)
+	}
+	for _, code := range Countries() {
+		list, err := Country(strings.ToLower(code))
+		if err != nil || len(list) == 0 {
+			t.Errorf("Country(%s) = %d holidays, %v", code, len(list), err)
+		}
+	}
+
+	gb, _ := Country("GB")
+	found := false
+	for _, holiday := range gb {
+		if holiday.Date == "2025-12-25" && holiday.Name == "Christmas Day" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected Christmas Day 2025 in the GB holidays")
+	}
+	if _, err := Country("XX"); err == nil {
+		t.Error("Country(XX) succeeded, want an error")
+	}
+}
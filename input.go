@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+	"gopkg.in/yaml.v3"
+)
+
+// inputRecordFields are the fields accepted in a structured entry record
+var inputRecordFields = map[string]bool{
+	"date":       true,
+	"issue":      true,
+	"alias":      true,
+	"duration":   true,
+	"seconds":    true,
+	"start":      true,
+	"comment":    true,
+	"visibility": true,
+}
+
+// inputVisibilityFields are the fields accepted in the visibility of a structured entry record
+var inputVisibilityFields = map[string]bool{
+	"type":  true,
+	"value": true,
+}
+
+// pointerEscaper escapes a key for use as a JSON pointer segment (RFC 6901)
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// RecordError reports an invalid field of a structured entry record
+type RecordError struct {
+	Pointer string // JSON pointer to the invalid value, e.g. /2/duration
+	Message string
+}
+
+// RecordErrors collects every validation error of a structured input
+type RecordErrors []RecordError
+
+func (e RecordErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, recordErr := range e {
+		lines = append(lines, fmt.Sprintf("%s: %s", recordErr.Pointer, recordErr.Message))
+	}
+	return fmt.Sprintf("%d invalid field(s) in input:\n  %s", len(e), strings.Join(lines, "\n  "))
+}
+
+// StdinIsTerminal reports whether stdin is attached to an interactive terminal
+func StdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ReadEntriesInput resolves the entries text from --entries, --entries-file or stdin.
+// "--entries -" reads stdin explicitly, and stdin is also read when it is not a terminal
+// so the interactive prompt is never used in pipelines. The boolean result is false when
+// no entries were supplied and the user should be prompted instead.
+func ReadEntriesInput(entriesOpt, entriesFile string, allowStdin bool) (string, bool, error) {
+	if entriesFile != "" {
+		if entriesOpt != "" {
+			return "", false, fmt.Errorf("--entries and --entries-file cannot be used together")
+		}
+		data, err := os.ReadFile(entriesFile)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read entries file: %v", err)
+		}
+		return string(data), true, nil
+	}
+
+	if entriesOpt == "-" || (entriesOpt == "" && allowStdin && !StdinIsTerminal()) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read entries from stdin: %v", err)
+		}
+		return string(data), true, nil
+	}
+
+	return entriesOpt, entriesOpt != "", nil
+}
+
+// ParseStructuredEntries parses JSON or YAML input into time entries. The input is a list of
+// records with the fields date, issue or alias, duration or seconds, start, comment and visibility.
+// Every record is validated and all errors are reported together with JSON-pointer paths.
+func ParseStructuredEntries(data string, format string, dateStr string, aliases map[string]string, logger *Logger) ([]TimeEntry, error) {
+	var document interface{}
+	switch strings.ToLower(format) {
+	case "json":
+		if err := json.Unmarshal([]byte(data), &document); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON input: %v", err)
+		}
+	case "yaml", "yml":
+		if err := yaml.Unmarshal([]byte(data), &document); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML input: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown input format %q, expected json or yaml", format)
+	}
+
+	if dateStr == "" {
+		dateStr = DefaultDateStr()
+	}
+
+	entries := []TimeEntry{}
+	if document == nil {
+		return entries, nil
+	}
+	records, ok := document.([]interface{})
+	if !ok {
+		return nil, RecordErrors{{Pointer: "", Message: "input must be a list of entry records"}}
+	}
+
+	var errs RecordErrors
+	for i, raw := range records {
+		pointer := fmt.Sprintf("/%d", i)
+		// The path below the record is given with its keys already escaped
+		fail := func(path, format string, args ...interface{}) {
+			errs = append(errs, RecordError{Pointer: pointer + "/" + path, Message: fmt.Sprintf(format, args...)})
+		}
+
+		record, ok := raw.(map[string]interface{})
+		if !ok {
+			errs = append(errs, RecordError{Pointer: pointer, Message: "record must be an object"})
+			continue
+		}
+
+		unknown := []string{}
+		for field := range record {
+			if !inputRecordFields[field] {
+				unknown = append(unknown, field)
+			}
+		}
+		sort.Strings(unknown)
+		for _, field := range unknown {
+			fail(pointerEscaper.Replace(field), "unknown field")
+		}
+
+		entry := TimeEntry{Date: dateStr}
+		stringField := func(field string) (string, bool) {
+			value, present := record[field]
+			if !present || value == nil {
+				return "", false
+			}
+			switch v := value.(type) {
+			case string:
+				return strings.TrimSpace(v), true
+			case time.Time:
+				// YAML may decode unquoted dates as timestamps
+				return v.Format("2006-01-02"), true
+			}
+			fail(field, "must be a string")
+			return "", false
+		}
+
+		// Date
+		if date, ok := stringField("date"); ok {
+			if _, err := ParseDate(date); err != nil {
+				fail("date", "invalid date %q, expected YYYY-MM-DD", date)
+			} else {
+				entry.Date = date
+			}
+		}
+
+		// Issue or alias
+		issue, hasIssue := stringField("issue")
+		alias, hasAlias := stringField("alias")
+		switch {
+		case hasIssue && hasAlias:
+			fail("alias", "only one of issue and alias may be given")
+		case hasIssue:
+			if issue == "" {
+				fail("issue", "must not be empty")
+			}
+			entry.Issue = issue
+		case hasAlias:
+			if aliasValue, ok := aliases[strings.ToLower(alias)]; ok {
+				logger.Info("Using alias '%s' -> %s", alias, aliasValue)
+				entry.Issue = aliasValue
+				entry.Alias = alias
+			} else {
+				fail("alias", "unknown alias %q", alias)
+			}
+		default:
+			fail("issue", "one of issue or alias is required")
+		}
+
+		// Duration or seconds, a bare number duration is a number of hours
+		duration, hasDuration := "", true
+		switch v := record["duration"].(type) {
+		case nil:
+			hasDuration = false
+		case string:
+			duration = strings.TrimSpace(v)
+		case float64:
+			duration = strconv.FormatFloat(v, 'f', -1, 64)
+		case int:
+			duration = strconv.Itoa(v)
+		default:
+			fail("duration", "must be a string or a number of hours")
+		}
+		hasSeconds := record["seconds"] != nil
+		switch {
+		case hasDuration && hasSeconds:
+			fail("seconds", "only one of duration and seconds may be given")
+		case hasDuration:
+			seconds, err := ToTimeSpentSeconds(duration)
+			if err != nil {
+				fail("duration", "%v", err)
+			} else if seconds <= 0 {
+				fail("duration", "must be greater than zero")
+			}
+			entry.Seconds = seconds
+		case hasSeconds:
+			seconds, ok := record["seconds"].(float64)
+			if intSeconds, isInt := record["seconds"].(int); isInt {
+				seconds, ok = float64(intSeconds), true
+			}
+			if !ok {
+				fail("seconds", "must be a number")
+			} else if seconds != math.Trunc(seconds) {
+				fail("seconds", "must be a whole number of seconds")
+			} else if seconds <= 0 {
+				fail("seconds", "must be greater than zero")
+			}
+			entry.Seconds = int(seconds)
+		default:
+			fail("duration", "one of duration or seconds is required")
+		}
+
+		// Start time
+		if start, ok := stringField("start"); ok {
+			if _, _, err := ParseClock(start); err != nil {
+				fail("start", "%v", err)
+			}
+			entry.Start = start
+		}
+
+		// Comment
+		if comment, ok := stringField("comment"); ok {
+			entry.Comment = comment
+		}
+
+		// Visibility
+		if raw, present := record["visibility"]; present && raw != nil {
+			visibility, ok := raw.(map[string]interface{})
+			if !ok {
+				fail("visibility", "must be an object with type and value")
+			} else {
+				unknown := []string{}
+				for field := range visibility {
+					if !inputVisibilityFields[field] {
+						unknown = append(unknown, field)
+					}
+				}
+				sort.Strings(unknown)
+				for _, field := range unknown {
+					fail("visibility/"+pointerEscaper.Replace(field), "unknown field")
+				}
+				visType, _ := visibility["type"].(string)
+				visValue, _ := visibility["value"].(string)
+				if visType != "group" && visType != "role" {
+					fail("visibility/type", "must be group or role")
+				}
+				if visValue == "" {
+					fail("visibility/value", "must be a non-empty string")
+				}
+				entry.Visibility = &jira.Visibility{Type: visType, Value: visValue}
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return entries, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// withStdin replaces stdin with a file holding the given text for the duration of the test
+func withStdin(t *testing.T, text string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = file
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		file.Close()
+	})
+}
+
+func TestReadEntriesInput(t *testing.T) {
+	entriesFile := filepath.Join(t.TempDir(), "entries.txt")
+	if err := os.WriteFile(entriesFile, []byte("meetings=1h\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		entries     string
+		entriesFile string
+		allowStdin  bool
+		want        string
+		supplied    bool
+		wantErr     bool
+	}{
+		{name: "entries option", entries: "PROJ-1=1h", allowStdin: true, want: "PROJ-1=1h", supplied: true},
+		{name: "entries file", entriesFile: entriesFile, allowStdin: true, want: "meetings=1h\n", supplied: true},
+		{name: "explicit stdin", entries: "-", want: "from stdin\n", supplied: true},
+		{name: "piped stdin", allowStdin: true, want: "from stdin\n", supplied: true},
+		{name: "stdin not allowed", want: "", supplied: false},
+		{name: "both options", entries: "PROJ-1=1h", entriesFile: entriesFile, wantErr: true},
+		{name: "missing file", entriesFile: filepath.Join(t.TempDir(), "missing.txt"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStdin(t, "from stdin\n")
+			got, supplied, err := ReadEntriesInput(tt.entries, tt.entriesFile, tt.allowStdin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadEntriesInput error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want || supplied != tt.supplied {
+				t.Errorf("ReadEntriesInput = %q, %v, want %q, %v", got, supplied, tt.want, tt.supplied)
+			}
+		})
+	}
+}
+
+func TestParseStructuredEntries(t *testing.T) {
+	aliases := map[string]string{"meetings": "PROJ-123"}
+	json := `[
+		{"alias": "meetings", "duration": "1h30m", "start": "09:30", "comment": "Planning"},
+		{"date": "2025-09-09", "issue": "PROJ-124", "seconds": 1800, "visibility": {"type": "role", "value": "Developers"}},
+		{"issue": "PROJ-125", "duration": 0.5}
+	]`
+	yaml := `
+- alias: meetings
+  duration: 1h30m
+  start: "09:30"
+  comment: Planning
+- date: 2025-09-09
+  issue: PROJ-124
+  seconds: 1800
+  visibility: {type: role, value: Developers}
+- issue: PROJ-125
+  duration: 0.5
+`
+	for format, data := range map[string]string{"json": json, "yaml": yaml} {
+		t.Run(format, func(t *testing.T) {
+			entries, err := ParseStructuredEntries(data, format, "2025-09-10", aliases, NewLogger("error"))
+			if err != nil {
+				t.Fatalf("ParseStructuredEntries: %v", err)
+			}
+			if len(entries) != 3 {
+				t.Fatalf("expected 3 entries, got %+v", entries)
+			}
+			first, second, third := entries[0], entries[1], entries[2]
+			if first.Date != "2025-09-10" || first.Issue != "PROJ-123" || first.Seconds != 5400 || first.Start != "09:30" || first.Comment != "Planning" {
+				t.Errorf("unexpected first entry %+v", first)
+			}
+			if second.Date != "2025-09-09" || second.Issue != "PROJ-124" || second.Seconds != 1800 ||
+				second.Visibility == nil || second.Visibility.Type != "role" || second.Visibility.Value != "Developers" {
+				t.Errorf("unexpected second entry %+v", second)
+			}
+			if third.Issue != "PROJ-125" || third.Seconds != 1800 {
+				t.Errorf("unexpected third entry %+v", third)
+			}
+		})
+	}
+}
+
+func TestParseStructuredEntriesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   []string // Pointers of the expected errors, in order
+	}{
+		{
+			name:   "not a list",
+			format: "json",
+			data:   `{"issue": "PROJ-1"}`,
+			want:   []string{""},
+		},
+		{
+			name:   "not an object",
+			format: "json",
+			data:   `[{"issue": "PROJ-1", "duration": "1h"}, "PROJ-2=1h"]`,
+			want:   []string{"/1"},
+		},
+		{
+			name:   "every invalid field of every record",
+			format: "json",
+			data: `[
+				{"issue": "PROJ-1", "duration": "1h", "colour": "red", "billable": true, "a/b~c": 1},
+				{"alias": "nope", "date": "2025-13-01", "duration": "lots"},
+				{"issue": "PROJ-1", "alias": "meetings", "duration": "1h", "seconds": 60},
+				{"issue": 7, "seconds": "60", "start": "25:00"},
+				{"issue": "PROJ-1", "duration": "1h", "visibility": {"type": "team", "scope": "all"}},
+				{"issue": "PROJ-1", "duration": 0, "visibility": "everyone"},
+				{"issue": "PROJ-1", "seconds": 90.5}
+			]`,
+			want: []string{
+				"/0/a~1b~0c", "/0/billable", "/0/colour",
+				"/1/date", "/1/alias", "/1/duration",
+				"/2/alias", "/2/seconds",
+				"/3/issue", "/3/issue", "/3/seconds", "/3/start",
+				"/4/visibility/scope", "/4/visibility/type", "/4/visibility/value",
+				"/5/duration", "/5/visibility",
+				"/6/seconds",
+			},
+		},
+		{
+			name:   "yaml",
+			format: "yaml",
+			data:   "- issue: PROJ-1\n- duration: 1h\n",
+			want:   []string{"/0/duration", "/1/issue"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStructuredEntries(tt.data, tt.format, "2025-09-10", map[string]string{"meetings": "PROJ-123"}, NewLogger("error"))
+			recordErrs, ok := err.(RecordErrors)
+			if !ok {
+				t.Fatalf("expected RecordErrors, got %v", err)
+			}
+			pointers := []string{}
+			for _, recordErr := range recordErrs {
+				pointers = append(pointers, recordErr.Pointer)
+			}
+			if !reflect.DeepEqual(pointers, tt.want) {
+				t.Errorf("error pointers\n got %q\nwant %q\n%v", pointers, tt.want, err)
+			}
+		})
+	}
+
+	for _, format := range []string{"json", "yaml", "toml"} {
+		if _, err := ParseStructuredEntries("[{", format, "2025-09-10", nil, NewLogger("error")); err == nil {
+			t.Errorf("expected a %s syntax error", format)
+		}
+	}
+}
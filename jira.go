@@ -1,15 +1,13 @@
 package main
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"strings"
 	"time"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
 )
 
 // Epic represents a Jira epic
@@ -21,36 +19,81 @@ type Epic struct {
 
 // WorklogResult represents the result of posting a worklog
 type WorklogResult struct {
+	Date    string
 	Issue   string
 	Seconds int
+	Started string
+	ID      string
 	Success bool
 	Code    int
 	Body    string
+	Message string // Readable error, parsed from the body when Jira sent its error format
 }
 
-// BuildWorklogPayload builds a worklog payload for the Jira API
-func BuildWorklogPayload(startedISO string, seconds int, apiVersion string) map[string]interface{} {
-	// Don't include comments as per user request
-	if apiVersion == "3" {
-		// Jira Cloud API v3 format
-		return map[string]interface{}{
-			"started":         startedISO,
-			"timeSpentSeconds": seconds,
-		}
-	} else {
-		// Jira Server/DC API v2 format
-		return map[string]interface{}{
-			"started":         startedISO,
-			"timeSpentSeconds": seconds,
-		}
+// ExistingWorklog represents a worklog already logged by the current user
+type ExistingWorklog struct {
+	ID      string
+	Issue   string
+	Summary string
+	Date    string
+	Started string
+	Seconds int
+}
+
+// Failure kinds of a worklog that could not be posted
+const (
+	FailureAuth       = "auth"
+	FailureValidation = "validation"
+	FailureRateLimit  = "rate-limit"
+	FailureServer     = "server"
+	FailureNetwork    = "network"
+)
+
+// ClassifyFailure returns the kind of failure for an HTTP status code (0 when no response was
+// received) and whether posting again may succeed. Auth and validation errors are permanent.
+func ClassifyFailure(code int) (string, bool) {
+	switch {
+	case code == 0:
+		return FailureNetwork, true
+	case code == 401 || code == 403:
+		return FailureAuth, false
+	case code == 408:
+		return FailureNetwork, true
+	case code == 429:
+		return FailureRateLimit, true
+	case code >= 500:
+		return FailureServer, true
+	default:
+		return FailureValidation, false
 	}
 }
 
+// NewJiraClient returns a Jira API client for the configured instance
+func NewJiraClient(settings *Settings, logger *Logger) jira.Client {
+	config := jira.Config{
+		BaseURL:  settings.JiraBaseURL,
+		Username: settings.JiraEmailOrUser,
+		Token:    settings.JiraAPIToken,
+		Logger:   logger.Slog(),
+	}
+	if httpTrace != nil {
+		config.HTTPClient = httpTrace.Client()
+	}
+	if settings.APIVersion == "2" {
+		return jira.NewV2(config)
+	}
+	return jira.NewV3(config)
+}
+
 // PostWorklog posts a worklog to a Jira issue
-func PostWorklog(settings *Settings, logger *Logger, issue string, seconds int, startedISO string) WorklogResult {
+func PostWorklog(settings *Settings, logger *Logger, entry TimeEntry) WorklogResult {
+	issue := entry.Issue
+	seconds := entry.Seconds
 	result := WorklogResult{
+		Date:    entry.Date,
 		Issue:   issue,
 		Seconds: seconds,
+		Started: entry.Started,
 		Success: false,
 	}
 
@@ -60,225 +103,224 @@ func PostWorklog(settings *Settings, logger *Logger, issue string, seconds int,
 		return result
 	}
 
-	// Create authentication header
-	auth := fmt.Sprintf("%s:%s", settings.JiraEmailOrUser, settings.JiraAPIToken)
-	encodedAuth := base64.StdEncoding.EncodeToString([]byte(auth))
-
-	// Create worklog URL
-	baseURL := strings.TrimSuffix(settings.JiraBaseURL, "/")
-	issueURL := fmt.Sprintf("%s/rest/api/%s/issue/%s/worklog", 
-		baseURL, settings.APIVersion, url.PathEscape(issue))
-
-	// Build payload
-	payload := BuildWorklogPayload(startedISO, seconds, settings.APIVersion)
-	payloadBytes, err := json.Marshal(payload)
+	logger.Debug("Posting worklog to %s with %d seconds using API v%s", issue, seconds, settings.APIVersion)
+	created, err := NewJiraClient(settings, logger).AddWorklog(context.Background(), issue, jira.NewWorklog{
+		Started:          entry.Started,
+		TimeSpentSeconds: seconds,
+		Comment:          entry.Comment,
+		Visibility:       entry.Visibility,
+	})
 	if err != nil {
-		result.Body = fmt.Sprintf("Failed to marshal payload: %v", err)
+		var apiErr *jira.APIError
+		if errors.As(err, &apiErr) {
+			result.Code = apiErr.StatusCode
+			result.Body = apiErr.Body
+			result.Message = apiErr.Message()
+			logger.Debug("HTTP %d response: %s", apiErr.StatusCode, apiErr.Body)
+		} else {
+			result.Body = err.Error()
+			result.Message = err.Error()
+		}
 		return result
 	}
 
-	// Create request
-	req, err := http.NewRequest("POST", issueURL, bytes.NewBuffer(payloadBytes))
+	// Keep the worklog ID so the worklog can be found again later
+	result.Success = true
+	result.ID = created.ID
+	return result
+}
+
+// GetAssignedIssues fetches issues assigned to the current user
+func GetAssignedIssues(settings *Settings, logger *Logger) ([]jira.Issue, error) {
+	jql := "assignee = currentUser() AND status NOT IN (Done, Closed, Completed, Wasted) AND key != 'CLOUD-1154' AND parent != 'CLOUD-1154'"
+	fields := []string{"key", "summary", "status", "parent", "issuetype", "customfield_10014"}
+
+	issues, err := NewJiraClient(settings, logger).SearchIssues(context.Background(), jql, fields)
 	if err != nil {
-		result.Body = fmt.Sprintf("Failed to create request: %v", err)
-		return result
+		return nil, err
 	}
 
-	// Add headers
-	req.Header.Set("Authorization", "Basic "+encodedAuth)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	logger.Debug("Found %d issues assigned to current user", len(issues))
+	return issues, nil
+}
 
-	// Log debug info
-	logger.Debug("Posting worklog to %s with %d seconds using API v%s", issue, seconds, settings.APIVersion)
-	payloadStr, _ := json.MarshalIndent(payload, "", "  ")
-	logger.Debug("Payload: %s", payloadStr)
-	logger.Debug("POST request to: %s", issueURL)
+// GetEpicsFromIssues extracts epics from issues
+func GetEpicsFromIssues(issues []jira.Issue) map[string]Epic {
+	epics := make(map[string]Epic)
+	for _, issue := range issues {
+		for _, epic := range issueEpics(issue) {
+			if _, exists := epics[epic.Key]; !exists {
+				epics[epic.Key] = epic
+			}
+		}
+	}
+	return epics
+}
 
-	// Send request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		result.Body = fmt.Sprintf("Failed to send request: %v", err)
-		return result
+// IssueEpic returns the epic an issue belongs to: the issue itself when it is an epic, otherwise
+// its parent or its epic link
+func IssueEpic(issue jira.Issue) (Epic, bool) {
+	if epics := issueEpics(issue); len(epics) > 0 {
+		return epics[0], true
 	}
-	defer resp.Body.Close()
+	return Epic{}, false
+}
 
-	// Read response
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		result.Body = fmt.Sprintf("Failed to read response: %v", err)
-		return result
+// issueEpics returns the epics of an issue, most specific first: the issue itself when it is
+// an epic, its parent, then its epic link (customfield_10014)
+func issueEpics(issue jira.Issue) []Epic {
+	issueKey := issue.Key
+	fields := issue.Fields
+	if issueKey == "" {
+		return nil
 	}
-	bodyStr := string(bodyBytes)
-	result.Code = resp.StatusCode
-	result.Body = bodyStr
 
-	// Check if successful
-	if resp.StatusCode == 200 || resp.StatusCode == 201 {
-		result.Success = true
-	} else {
-		logger.Error("HTTP %d response: %s", resp.StatusCode, bodyStr)
+	epics := []Epic{}
+	if issue.IsEpic() && issueKey != "CLOUD-1154" {
+		epics = append(epics, Epic{
+			Key:     issueKey,
+			Summary: fields.Summary,
+			Type:    "epic",
+		})
 	}
+	if parent := fields.Parent; parent != nil && parent.Key != "" && parent.Key != "CLOUD-1154" {
+		summary := "No summary"
+		if parent.Fields != nil {
+			summary = parent.Fields.Summary
+		}
+		epics = append(epics, Epic{
+			Key:     parent.Key,
+			Summary: summary,
+			Type:    "parent",
+		})
+	}
+	if epicField := fields.EpicLink; epicField != "" && epicField != "CLOUD-1154" {
+		epics = append(epics, Epic{
+			Key:     epicField,
+			Summary: fmt.Sprintf("Epic: %s", epicField),
+			Type:    "epic_link",
+		})
+	}
+	return epics
+}
 
-	return result
+// DeleteWorklog deletes a worklog from a Jira issue
+func DeleteWorklog(settings *Settings, logger *Logger, issue, worklogID string) error {
+	return NewJiraClient(settings, logger).DeleteWorklog(context.Background(), issue, worklogID)
 }
 
-// GetAssignedIssues fetches issues assigned to the current user
-func GetAssignedIssues(settings *Settings, logger *Logger) ([]map[string]interface{}, error) {
-	// Create authentication header
-	auth := fmt.Sprintf("%s:%s", settings.JiraEmailOrUser, settings.JiraAPIToken)
-	encodedAuth := base64.StdEncoding.EncodeToString([]byte(auth))
-
-	// Build URL and query
-	baseURL := strings.TrimSuffix(settings.JiraBaseURL, "/")
-	var apiURL string
-	
-	if settings.APIVersion == "3" {
-		// Jira Cloud API v3
-		apiURL = fmt.Sprintf("%s/rest/api/3/search/jql", baseURL)
-	} else {
-		// Jira Server/DC API v2
-		apiURL = fmt.Sprintf("%s/rest/api/2/search", baseURL)
+// JQLKeyList formats issue keys for a JQL "key in (...)" clause
+func JQLKeyList(keys []string) string {
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		quoted = append(quoted, fmt.Sprintf("%q", key))
 	}
-	
-	// Create JQL query
-	jql := "assignee = currentUser() AND status NOT IN (Done, Closed, Completed, Wasted) AND key != 'CLOUD-1154' AND parent != 'CLOUD-1154'"
-	
-	// Build query parameters depending on the API version
-	var fullURL string
-	if settings.APIVersion == "3" {
-		// For the new JQL endpoint, we need to POST the query with the fields parameter in the body
-		fullURL = apiURL
-	} else {
-		// Keep the old format for API v2
-		queryParams := url.Values{
-			"jql":        {jql},
-			"fields":     {"key,summary,parent,issuetype,customfield_10014"},
-			"maxResults": {"100"},
-		}
-		fullURL = fmt.Sprintf("%s?%s", apiURL, queryParams.Encode())
+	return strings.Join(quoted, ", ")
+}
+
+// GetIssueSummaries fetches the summaries of the given issues with a single JQL query,
+// keyed by upper-case issue key
+func GetIssueSummaries(settings *Settings, logger *Logger, keys []string) (map[string]string, error) {
+	summaries := map[string]string{}
+	if len(keys) == 0 {
+		return summaries, nil
 	}
-	
-	logger.Debug("Fetching issues from API endpoint: %s", fullURL)
-	
-	var req *http.Request
-	var err error
-	
-	if settings.APIVersion == "3" {
-		// Create POST request with JSON body for API v3
-		requestBody := map[string]interface{}{
-			"jql": jql,
-			"fields": []string{"key", "summary", "parent", "issuetype", "customfield_10014"},
-			"maxResults": 100,
-		}
-		requestJSON, err := json.Marshal(requestBody)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal JSON request: %v", err)
-		}
-		
-		req, err = http.NewRequest("POST", fullURL, bytes.NewBuffer(requestJSON))
-	} else {
-		// Create GET request for API v2
-		req, err = http.NewRequest("GET", fullURL, nil)
+
+	jql := fmt.Sprintf("key in (%s)", JQLKeyList(keys))
+	issues, err := NewJiraClient(settings, logger).SearchIssues(context.Background(), jql, []string{"summary"})
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		summaries[strings.ToUpper(issue.Key)] = issue.Fields.Summary
+	}
+	return summaries, nil
+}
+
+// GetIssueDetails fetches the given issues with their summary, type, parent and epic link,
+// keyed by upper-case issue key
+func GetIssueDetails(settings *Settings, logger *Logger, keys []string) (map[string]jira.Issue, error) {
+	details := map[string]jira.Issue{}
+	if len(keys) == 0 {
+		return details, nil
 	}
-	
+
+	jql := fmt.Sprintf("key in (%s)", JQLKeyList(keys))
+	fields := []string{"summary", "parent", "issuetype", "customfield_10014"}
+	issues, err := NewJiraClient(settings, logger).SearchIssues(context.Background(), jql, fields)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+		return nil, err
 	}
-	
-	// Add headers
-	req.Header.Set("Authorization", "Basic "+encodedAuth)
-	req.Header.Set("Accept", "application/json")
-	
-	// Add Content-Type header for POST requests
-	if settings.APIVersion == "3" {
-		req.Header.Set("Content-Type", "application/json")
+	for _, issue := range issues {
+		details[strings.ToUpper(issue.Key)] = issue
 	}
-	
-	// Send request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	return details, nil
+}
+
+// GetMyWorklogs fetches the current user's worklogs started between two dates (inclusive, YYYY-MM-DD)
+func GetMyWorklogs(settings *Settings, logger *Logger, fromDate, toDate string) ([]ExistingWorklog, error) {
+	loc := LoadTimezone(settings.Timezone, logger)
+	from, err := time.ParseInLocation("2006-01-02", fromDate, loc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-	
-	// Check response status
-	if resp.StatusCode != 200 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned error: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+	to, err := time.ParseInLocation("2006-01-02", toDate, loc)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Parse response
-	var result struct {
-		Issues []map[string]interface{} `json:"issues"`
+
+	client := NewJiraClient(settings, logger)
+	ctx := context.Background()
+
+	user, err := client.Myself(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify current user: %v", err)
 	}
-	
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %v", err)
+	userID := user.ID()
+
+	jql := fmt.Sprintf(`worklogAuthor = currentUser() AND worklogDate >= "%s" AND worklogDate <= "%s"`, fromDate, toDate)
+	issues, err := client.SearchIssues(ctx, jql, []string{"summary"})
+	if err != nil {
+		return nil, err
 	}
-	
-	logger.Debug("Found %d issues assigned to current user", len(result.Issues))
-	return result.Issues, nil
-}
 
-// GetEpicsFromIssues extracts epics from issues
-func GetEpicsFromIssues(issues []map[string]interface{}) map[string]Epic {
-	epics := make(map[string]Epic)
-	
+	worklogs := []ExistingWorklog{}
 	for _, issue := range issues {
-		issueKey, _ := issue["key"].(string)
-		fields, ok := issue["fields"].(map[string]interface{})
-		if !ok || issueKey == "" {
+		issueKey := issue.Key
+		if issueKey == "" {
 			continue
 		}
-		
-		// Check if issue is an epic
-		issueType, _ := fields["issuetype"].(map[string]interface{})
-		if issueType != nil {
-			typeName, _ := issueType["name"].(string)
-			if strings.ToLower(typeName) == "epic" && issueKey != "CLOUD-1154" {
-				summary, _ := fields["summary"].(string)
-				epics[issueKey] = Epic{
-					Key:     issueKey,
-					Summary: summary,
-					Type:    "epic",
-				}
-			}
+		summary := issue.Fields.Summary
+
+		issueWorklogs, err := client.GetWorklogs(ctx, issueKey, from.Add(-time.Millisecond))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s worklogs: %v", issueKey, err)
 		}
-		
-		// Check parent
-		parent, _ := fields["parent"].(map[string]interface{})
-		if parent != nil {
-			parentKey, _ := parent["key"].(string)
-			if parentKey != "" && parentKey != "CLOUD-1154" {
-				if _, exists := epics[parentKey]; !exists {
-					parentFields, _ := parent["fields"].(map[string]interface{})
-					summary := "No summary"
-					if parentFields != nil {
-						summary, _ = parentFields["summary"].(string)
-					}
-					epics[parentKey] = Epic{
-						Key:     parentKey,
-						Summary: summary,
-						Type:    "parent",
-					}
-				}
+
+		for _, w := range issueWorklogs {
+			if !w.Author.Matches(userID) {
+				continue
 			}
-		}
-		
-		// Check for epic link (customfield_10014)
-		if epicField, ok := fields["customfield_10014"].(string); ok && epicField != "" && epicField != "CLOUD-1154" {
-			if _, exists := epics[epicField]; !exists {
-				epics[epicField] = Epic{
-					Key:     epicField,
-					Summary: fmt.Sprintf("Epic: %s", epicField),
-					Type:    "epic_link",
-				}
+			started, err := w.StartedTime()
+			if err != nil {
+				logger.Debug("Skipping worklog %s on %s with unparseable start %s", w.ID, issueKey, w.Started)
+				continue
+			}
+			day := started.In(loc)
+			if day.Before(from) || !day.Before(to.AddDate(0, 0, 1)) {
+				continue
 			}
+			worklogs = append(worklogs, ExistingWorklog{
+				ID:      w.ID,
+				Issue:   issueKey,
+				Summary: summary,
+				Date:    day.Format("2006-01-02"),
+				Started: w.Started,
+				Seconds: w.TimeSpentSeconds,
+			})
 		}
 	}
-	
-	return epics
+
+	logger.Debug("Found %d worklogs between %s and %s", len(worklogs), fromDate, toDate)
+	return worklogs, nil
 }
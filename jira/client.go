@@ -0,0 +1,79 @@
+// Package jira is a small client for the Jira REST API, covering what is needed to log work:
+// issue search, the current user and worklogs. Jira Cloud is served by API v3, Jira Server and
+// Data Center by API v2.
+package jira
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client is a Jira REST API client
+type Client interface {
+	// SearchIssues runs a JQL query and returns all matching issues, following pagination
+	SearchIssues(ctx context.Context, jql string, fields []string) ([]Issue, error)
+	// AddWorklog adds a worklog to an issue and returns the created worklog
+	AddWorklog(ctx context.Context, issueKey string, worklog NewWorklog) (*Worklog, error)
+	// GetWorklogs returns the worklogs of an issue started after the given time, all when it is zero
+	GetWorklogs(ctx context.Context, issueKey string, startedAfter time.Time) ([]Worklog, error)
+	// UpdateWorklog replaces the start, time spent, comment and visibility of a worklog
+	UpdateWorklog(ctx context.Context, issueKey, worklogID string, worklog NewWorklog) (*Worklog, error)
+	// DeleteWorklog deletes a worklog of an issue
+	DeleteWorklog(ctx context.Context, issueKey, worklogID string) error
+	// Myself returns the authenticated user
+	Myself(ctx context.Context) (*User, error)
+}
+
+// Config configures a client
+type Config struct {
+	BaseURL    string
+	Username   string // Email on Jira Cloud, username on Jira Server
+	Token      string // API token on Jira Cloud, password or personal access token on Jira Server
+	APIVersion string // "2" or "3", used by New
+
+	HTTPClient *http.Client // Defaults to a client with a 30 second timeout
+	Logger     *slog.Logger // Optional, requests and responses are logged at debug level
+}
+
+// New returns a client for the API version in the config
+func New(config Config) (Client, error) {
+	switch config.APIVersion {
+	case "2":
+		return NewV2(config), nil
+	case "3", "":
+		return NewV3(config), nil
+	default:
+		return nil, fmt.Errorf("unsupported API version %q, must be 2 or 3", config.APIVersion)
+	}
+}
+
+// NewV2 returns a client for the Jira Server/Data Center REST API v2
+func NewV2(config Config) Client {
+	return &v2Client{base{newTransport(config, "2")}}
+}
+
+// NewV3 returns a client for the Jira Cloud REST API v3
+func NewV3(config Config) Client {
+	return &v3Client{base{newTransport(config, "3")}}
+}
+
+// defaultHTTPClient is shared by all clients without their own, so connections are reused
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func newTransport(config Config, version string) *transport {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	return &transport{
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/") + "/rest/api/" + version,
+		username: config.Username,
+		token:    config.Token,
+		http:     httpClient,
+		logger:   config.Logger,
+	}
+}
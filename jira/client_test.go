@@ -0,0 +1,247 @@
+package jira_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+)
+
+// fakeJira records the requests it receives and answers them with handle
+type fakeJira struct {
+	requests []string
+	payloads []map[string]interface{}
+	handle   func(w http.ResponseWriter, r *http.Request, payload map[string]interface{})
+}
+
+// newFakeClient returns a client of an API version talking to the fake
+func newFakeClient(t *testing.T, version string, fake *fakeJira) jira.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := "Basic " + base64.StdEncoding.EncodeToString([]byte("jane.doe@example.com:secret"))
+		if r.Header.Get("Authorization") != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload map[string]interface{}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&payload)
+		}
+		fake.requests = append(fake.requests, r.Method+" "+r.URL.Path)
+		fake.payloads = append(fake.payloads, payload)
+		fake.handle(w, r, payload)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := jira.New(jira.Config{BaseURL: server.URL + "/", Username: "jane.doe@example.com", Token: "secret", APIVersion: version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// issuePage returns count issues named BULK-n from start on
+func issuePage(start, count int) []map[string]interface{} {
+	issues := []map[string]interface{}{}
+	for i := start; i < start+count; i++ {
+		issues = append(issues, map[string]interface{}{"key": fmt.Sprintf("BULK-%d", i+1), "fields": map[string]interface{}{"summary": "Issue"}})
+	}
+	return issues
+}
+
+func TestNew(t *testing.T) {
+	for _, version := range []string{"", "2", "3"} {
+		if _, err := jira.New(jira.Config{APIVersion: version}); err != nil {
+			t.Errorf("New(%q): %v", version, err)
+		}
+	}
+	if _, err := jira.New(jira.Config{APIVersion: "4"}); err == nil {
+		t.Error("expected an unsupported API version error")
+	}
+}
+
+func TestSearchIssues(t *testing.T) {
+	const total = 150
+	want := []string{}
+	for i := 1; i <= total; i++ {
+		want = append(want, fmt.Sprintf("BULK-%d", i))
+	}
+
+	t.Run("v2 pages with startAt", func(t *testing.T) {
+		fake := &fakeJira{handle: func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+			query := r.URL.Query()
+			startAt, _ := strconv.Atoi(query.Get("startAt"))
+			if query.Get("jql") != "project = BULK" || query.Get("fields") != "summary,parent" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			count := total - startAt
+			if count > 100 {
+				count = 100
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"startAt": startAt, "total": total, "issues": issuePage(startAt, count)})
+		}}
+		issues, err := newFakeClient(t, "2", fake).SearchIssues(context.Background(), "project = BULK", []string{"summary", "parent"})
+		if err != nil {
+			t.Fatalf("SearchIssues: %v", err)
+		}
+		assertKeys(t, issues, want)
+		if !reflect.DeepEqual(fake.requests, []string{"GET /rest/api/2/search", "GET /rest/api/2/search"}) {
+			t.Errorf("unexpected requests %q", fake.requests)
+		}
+	})
+
+	t.Run("v3 pages with nextPageToken", func(t *testing.T) {
+		fake := &fakeJira{handle: func(w http.ResponseWriter, r *http.Request, payload map[string]interface{}) {
+			if payload["jql"] != "project = BULK" || payload["nextPageToken"] != nil && payload["nextPageToken"] != "page-2" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if payload["nextPageToken"] == nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"issues": issuePage(0, 100), "nextPageToken": "page-2"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"issues": issuePage(100, total-100), "isLast": true})
+		}}
+		issues, err := newFakeClient(t, "3", fake).SearchIssues(context.Background(), "project = BULK", []string{"summary", "parent"})
+		if err != nil {
+			t.Fatalf("SearchIssues: %v", err)
+		}
+		assertKeys(t, issues, want)
+		if !reflect.DeepEqual(fake.requests, []string{"POST /rest/api/3/search/jql", "POST /rest/api/3/search/jql"}) {
+			t.Errorf("unexpected requests %q", fake.requests)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		fake := &fakeJira{handle: func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errorMessages":["An issue with key 'NOPE-1' does not exist for field 'key'."]}`))
+		}}
+		_, err := newFakeClient(t, "3", fake).SearchIssues(context.Background(), "key = NOPE-1", nil)
+		if jira.StatusCode(err) != 400 {
+			t.Errorf("expected HTTP 400, got %v", err)
+		}
+	})
+}
+
+// assertKeys checks the keys of issues, in order
+func assertKeys(t *testing.T, issues []jira.Issue, want []string) {
+	t.Helper()
+	keys := []string{}
+	for _, issue := range issues {
+		keys = append(keys, issue.Key)
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("expected %d issues in order, got %v", len(want), keys)
+	}
+}
+
+func TestAddWorklog(t *testing.T) {
+	tests := []struct {
+		version string
+		comment interface{}
+	}{
+		{"2", "Paged at night"},
+		{"3", map[string]interface{}{"type": "doc", "version": float64(1), "content": []interface{}{
+			map[string]interface{}{"type": "paragraph", "content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "Paged at night"},
+			}},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run("v"+tt.version, func(t *testing.T) {
+			fake := &fakeJira{handle: func(w http.ResponseWriter, r *http.Request, payload map[string]interface{}) {
+				created := map[string]interface{}{"id": "10000"}
+				for key, value := range payload {
+					created[key] = value
+				}
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(created)
+			}}
+			client := newFakeClient(t, tt.version, fake)
+
+			worklog := jira.NewWorklog{
+				Started:          "2025-09-09T17:00:00.000+0100",
+				TimeSpentSeconds: 5400,
+				Comment:          "Paged at night",
+				Visibility:       &jira.Visibility{Type: "role", Value: "Developers"},
+			}
+			created, err := client.AddWorklog(context.Background(), "OPS-7", worklog)
+			if err != nil {
+				t.Fatalf("AddWorklog: %v", err)
+			}
+			if created.ID != "10000" || created.TimeSpentSeconds != 5400 || created.CommentText() != "Paged at night" {
+				t.Errorf("unexpected created worklog %+v", created)
+			}
+
+			want := map[string]interface{}{
+				"started":          "2025-09-09T17:00:00.000+0100",
+				"timeSpentSeconds": float64(5400),
+				"comment":          tt.comment,
+				"visibility":       map[string]interface{}{"type": "role", "value": "Developers"},
+			}
+			if fake.requests[0] != "POST /rest/api/"+tt.version+"/issue/OPS-7/worklog" || !reflect.DeepEqual(fake.payloads[0], want) {
+				t.Errorf("unexpected request %s\n got %v\nwant %v", fake.requests[0], fake.payloads[0], want)
+			}
+
+			// Worklogs without a comment leave it out
+			if _, err := client.AddWorklog(context.Background(), "OPS-7", jira.NewWorklog{Started: worklog.Started, TimeSpentSeconds: 60}); err != nil {
+				t.Fatalf("AddWorklog: %v", err)
+			}
+			if _, ok := fake.payloads[1]["comment"]; ok {
+				t.Errorf("expected no comment, got %v", fake.payloads[1])
+			}
+		})
+	}
+}
+
+func TestGetWorklogs(t *testing.T) {
+	after, _ := time.Parse(jira.TimeLayout, "2025-09-09T00:00:00.000+0100")
+	fake := &fakeJira{handle: func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		query := r.URL.Query()
+		if query.Get("startedAfter") != strconv.FormatInt(after.UnixMilli(), 10) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		startAt, _ := strconv.Atoi(query.Get("startAt"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"startAt":  startAt,
+			"total":    2,
+			"worklogs": []map[string]interface{}{{"id": strconv.Itoa(10000 + startAt), "timeSpentSeconds": 3600}},
+		})
+	}}
+	worklogs, err := newFakeClient(t, "3", fake).GetWorklogs(context.Background(), "PROJ-124", after)
+	if err != nil {
+		t.Fatalf("GetWorklogs: %v", err)
+	}
+	if len(worklogs) != 2 || worklogs[0].ID != "10000" || worklogs[1].ID != "10001" {
+		t.Errorf("expected both pages of worklogs, got %+v", worklogs)
+	}
+}
+
+func TestDeleteWorklog(t *testing.T) {
+	fake := &fakeJira{handle: func(w http.ResponseWriter, r *http.Request, _ map[string]interface{}) {
+		if r.URL.Path != "/rest/api/2/issue/PROJ-124/worklog/10000" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}}
+	client := newFakeClient(t, "2", fake)
+	if err := client.DeleteWorklog(context.Background(), "PROJ-124", "10000"); err != nil {
+		t.Errorf("DeleteWorklog: %v", err)
+	}
+	if err := client.DeleteWorklog(context.Background(), "PROJ-124", "99999"); !errors.Is(err, jira.ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
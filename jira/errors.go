@@ -0,0 +1,126 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Errors matched by an *APIError with errors.Is, by HTTP status code
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// APIError is returned when the Jira API responds with an unexpected status code. Messages
+// and FieldErrors hold Jira's errorMessages and errors when the body has them.
+type APIError struct {
+	Method      string
+	Path        string
+	StatusCode  int
+	Body        string
+	Messages    []string
+	FieldErrors map[string]string
+}
+
+// newAPIError builds an *APIError, parsing the error messages out of the body
+func newAPIError(method, path string, statusCode int, body []byte) *APIError {
+	apiErr := &APIError{Method: method, Path: path, StatusCode: statusCode, Body: string(body)}
+
+	var parsed struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+		ErrorMessage  string            `json:"errorMessage"` // Some Server plugins
+		Message       string            `json:"message"`      // Gateway errors, e.g. rate limiting on Cloud
+	}
+	if json.Unmarshal(body, &parsed) != nil {
+		return apiErr
+	}
+	for _, message := range append(parsed.ErrorMessages, parsed.ErrorMessage, parsed.Message) {
+		if message = strings.TrimSpace(message); message != "" {
+			apiErr.Messages = append(apiErr.Messages, message)
+		}
+	}
+	if len(parsed.Errors) > 0 {
+		apiErr.FieldErrors = parsed.Errors
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message())
+}
+
+// Message returns a readable description of the error: Jira's messages and field errors when
+// the body has them, otherwise the start of the body or the status text
+func (e *APIError) Message() string {
+	parts := append([]string{}, e.Messages...)
+	fields := make([]string, 0, len(e.FieldErrors))
+	for field := range e.FieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", field, e.FieldErrors[field]))
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, "; ")
+	}
+
+	body := strings.Join(strings.Fields(e.Body), " ")
+	if body == "" {
+		return http.StatusText(e.StatusCode)
+	}
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	return body
+}
+
+// Is reports whether the status code of the error matches one of the sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == 401
+	case ErrForbidden:
+		return e.StatusCode == 403
+	case ErrNotFound:
+		return e.StatusCode == 404
+	case ErrRateLimited:
+		return e.StatusCode == 429
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// RequestError is returned when a request could not be sent or its response could not be read.
+// No status code is available.
+type RequestError struct {
+	Method string
+	Path   string
+	Err    error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Method, e.Path, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the HTTP status code of an *APIError in the chain of err, 0 otherwise
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package jira
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{
+			name:   "messages and field errors",
+			status: 400,
+			body:   `{"errorMessages":["Worklog must not be null"],"errors":{"timeLogged":"Invalid time duration","started":"Invalid date"}}`,
+			want:   "Worklog must not be null; started: Invalid date; timeLogged: Invalid time duration",
+		},
+		{
+			name:   "gateway message",
+			status: 429,
+			body:   `{"message":"Rate limit exceeded"}`,
+			want:   "Rate limit exceeded",
+		},
+		{
+			name:   "plugin error message",
+			status: 500,
+			body:   `{"errorMessage":"  Internal error  "}`,
+			want:   "Internal error",
+		},
+		{
+			name:   "not json",
+			status: 502,
+			body:   "<html>\n  <body>Bad Gateway</body>\n</html>",
+			want:   "<html> <body>Bad Gateway</body> </html>",
+		},
+		{
+			name:   "long body",
+			status: 500,
+			body:   strings.Repeat("x", 300),
+			want:   strings.Repeat("x", 200) + "...",
+		},
+		{
+			name:   "empty body",
+			status: 404,
+			want:   "Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := newAPIError("POST", "/issue/PROJ-1/worklog", tt.status, []byte(tt.body))
+			if got := apiErr.Message(); got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+			if got, want := apiErr.Error(), fmt.Sprintf("HTTP %d: %s", tt.status, tt.want); got != want {
+				t.Errorf("Error() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited, ErrServer}
+	tests := []struct {
+		status int
+		want   error // nil when no sentinel matches
+	}{
+		{400, nil},
+		{401, ErrUnauthorized},
+		{403, ErrForbidden},
+		{404, ErrNotFound},
+		{408, nil},
+		{429, ErrRateLimited},
+		{500, ErrServer},
+		{503, ErrServer},
+	}
+	for _, tt := range tests {
+		// Callers see the error wrapped
+		err := fmt.Errorf("adding worklog: %w", newAPIError("POST", "/issue/PROJ-1/worklog", tt.status, nil))
+		for _, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+				t.Errorf("HTTP %d: errors.Is(%v) = %v", tt.status, sentinel, got)
+			}
+		}
+		if got := StatusCode(err); got != tt.status {
+			t.Errorf("StatusCode() = %d, want %d", got, tt.status)
+		}
+	}
+
+	requestErr := &RequestError{Method: "GET", Path: "/myself", Err: errors.New("connection refused")}
+	if StatusCode(requestErr) != 0 || errors.Is(requestErr, ErrServer) {
+		t.Errorf("a request error has no status code")
+	}
+}
@@ -0,0 +1,324 @@
+package jira
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// TimeLayout is the timestamp format used by the Jira REST API
+const TimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Issue is a Jira issue as returned by the search endpoints
+type Issue struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Self   string `json:"self,omitempty"`
+	Fields Fields `json:"fields"`
+
+	// Extra holds the properties without a typed counterpart, such as expand data
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Fields holds the issue fields requested by the tool
+type Fields struct {
+	Summary   string     `json:"summary,omitempty"`
+	IssueType *IssueType `json:"issuetype,omitempty"`
+	Status    *Status    `json:"status,omitempty"`
+	Project   *Project   `json:"project,omitempty"`
+	Parent    *Parent    `json:"parent,omitempty"`
+
+	// EpicLink is the legacy "Epic Link" field (customfield_10014)
+	EpicLink string `json:"-"`
+
+	// Extra holds the fields without a typed counterpart, such as other custom fields
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// IssueType is the type of an issue, e.g. Epic or Task
+type IssueType struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name"`
+	Subtask bool   `json:"subtask,omitempty"`
+}
+
+// Status is the workflow status of an issue
+type Status struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// Project is the project an issue belongs to
+type Project struct {
+	ID   string `json:"id,omitempty"`
+	Key  string `json:"key"`
+	Name string `json:"name,omitempty"`
+}
+
+// Parent is the parent of an issue, an epic or the issue a subtask belongs to
+type Parent struct {
+	ID     string  `json:"id,omitempty"`
+	Key    string  `json:"key"`
+	Fields *Fields `json:"fields,omitempty"`
+}
+
+// User is a Jira user. Jira Cloud identifies users by account ID, Jira Server by name and key.
+type User struct {
+	AccountID    string `json:"accountId,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Key          string `json:"key,omitempty"`
+	EmailAddress string `json:"emailAddress,omitempty"`
+	DisplayName  string `json:"displayName,omitempty"`
+	Active       bool   `json:"active"`
+	TimeZone     string `json:"timeZone,omitempty"`
+
+	// Extra holds the properties without a typed counterpart
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Worklog is a worklog of an issue
+type Worklog struct {
+	ID               string          `json:"id"`
+	IssueID          string          `json:"issueId,omitempty"`
+	Self             string          `json:"self,omitempty"`
+	Author           *User           `json:"author,omitempty"`
+	UpdateAuthor     *User           `json:"updateAuthor,omitempty"`
+	Comment          json.RawMessage `json:"comment,omitempty"` // Plain text in v2, Atlassian Document Format in v3
+	Started          string          `json:"started"`
+	TimeSpent        string          `json:"timeSpent,omitempty"`
+	TimeSpentSeconds int             `json:"timeSpentSeconds"`
+	Visibility       *Visibility     `json:"visibility,omitempty"`
+
+	// Extra holds the properties without a typed counterpart
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// Visibility restricts who can see a worklog
+type Visibility struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// NewWorklog is a worklog to add, or the new values of a worklog to update
+type NewWorklog struct {
+	Started          string // In TimeLayout
+	TimeSpentSeconds int
+	Comment          string
+	Visibility       *Visibility
+}
+
+// SearchPage is a page of issue search results. API v2 pages with startAt and total,
+// the v3 search/jql endpoint with nextPageToken and isLast.
+type SearchPage struct {
+	StartAt       int     `json:"startAt"`
+	MaxResults    int     `json:"maxResults"`
+	Total         int     `json:"total"`
+	Issues        []Issue `json:"issues"`
+	NextPageToken string  `json:"nextPageToken,omitempty"`
+	IsLast        bool    `json:"isLast,omitempty"`
+}
+
+// WorklogPage is a page of the worklogs of an issue
+type WorklogPage struct {
+	StartAt    int       `json:"startAt"`
+	MaxResults int       `json:"maxResults"`
+	Total      int       `json:"total"`
+	Worklogs   []Worklog `json:"worklogs"`
+}
+
+// ID returns the identifier Jira uses for the user: the account ID on Cloud, the name or key on Server
+func (u *User) ID() string {
+	if u == nil {
+		return ""
+	}
+	if u.AccountID != "" {
+		return u.AccountID
+	}
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Key
+}
+
+// Matches reports whether the user has the given identifier
+func (u *User) Matches(id string) bool {
+	return u != nil && id != "" && (u.AccountID == id || u.Name == id || u.Key == id)
+}
+
+// IsEpic reports whether the issue is an epic
+func (i *Issue) IsEpic() bool {
+	return i.Fields.IssueType != nil && strings.EqualFold(i.Fields.IssueType.Name, "epic")
+}
+
+// StartedTime parses the start timestamp of the worklog
+func (w *Worklog) StartedTime() (time.Time, error) {
+	return time.Parse(TimeLayout, w.Started)
+}
+
+// CommentText returns the worklog comment as plain text, for both API versions
+func (w *Worklog) CommentText() string {
+	if len(w.Comment) == 0 {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(w.Comment, &text); err == nil {
+		return text
+	}
+
+	var doc adfNode
+	if err := json.Unmarshal(w.Comment, &doc); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.text())
+}
+
+// adfNode is a node of an Atlassian Document Format document
+type adfNode struct {
+	Type    string    `json:"type"`
+	Version int       `json:"version,omitempty"`
+	Text    string    `json:"text,omitempty"`
+	Content []adfNode `json:"content,omitempty"`
+}
+
+// text concatenates the text of the node and its children, one line per paragraph
+func (n adfNode) text() string {
+	var b strings.Builder
+	b.WriteString(n.Text)
+	for _, child := range n.Content {
+		b.WriteString(child.text())
+	}
+	if n.Type == "paragraph" {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// splitUnknown decodes data into a map and returns the properties not listed in known
+func splitUnknown(data []byte, known ...string) (map[string]json.RawMessage, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for _, key := range known {
+		delete(all, key)
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all, nil
+}
+
+// mergeUnknown encodes v and adds the extra properties back
+func mergeUnknown(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, exists := all[key]; !exists {
+			all[key] = value
+		}
+	}
+	return json.Marshal(all)
+}
+
+// UnmarshalJSON decodes an issue, keeping unknown properties in Extra
+func (i *Issue) UnmarshalJSON(data []byte) error {
+	type plain Issue
+	if err := json.Unmarshal(data, (*plain)(i)); err != nil {
+		return err
+	}
+	extra, err := splitUnknown(data, "id", "key", "self", "fields")
+	i.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes an issue including the unknown properties
+func (i Issue) MarshalJSON() ([]byte, error) {
+	type plain Issue
+	return mergeUnknown(plain(i), i.Extra)
+}
+
+// UnmarshalJSON decodes issue fields, keeping fields without a typed counterpart in Extra
+func (f *Fields) UnmarshalJSON(data []byte) error {
+	type plain Fields
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+
+	extra, err := splitUnknown(data, "summary", "issuetype", "status", "project", "parent")
+	if err != nil {
+		return err
+	}
+
+	// The epic link is usually a plain issue key or null, anything else stays in Extra
+	if raw, ok := extra["customfield_10014"]; ok {
+		var epicLink *string
+		if err := json.Unmarshal(raw, &epicLink); err == nil {
+			if epicLink != nil {
+				f.EpicLink = *epicLink
+			}
+			delete(extra, "customfield_10014")
+		}
+	}
+
+	if len(extra) > 0 {
+		f.Extra = extra
+	}
+	return nil
+}
+
+// MarshalJSON encodes issue fields including the epic link and the unknown fields
+func (f Fields) MarshalJSON() ([]byte, error) {
+	type plain Fields
+	extra := f.Extra
+	if f.EpicLink != "" {
+		extra = map[string]json.RawMessage{}
+		for key, value := range f.Extra {
+			extra[key] = value
+		}
+		extra["customfield_10014"], _ = json.Marshal(f.EpicLink)
+	}
+	return mergeUnknown(plain(f), extra)
+}
+
+// UnmarshalJSON decodes a user, keeping unknown properties in Extra
+func (u *User) UnmarshalJSON(data []byte) error {
+	type plain User
+	if err := json.Unmarshal(data, (*plain)(u)); err != nil {
+		return err
+	}
+	extra, err := splitUnknown(data, "accountId", "name", "key", "emailAddress", "displayName", "active", "timeZone")
+	u.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes a user including the unknown properties
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return mergeUnknown(plain(u), u.Extra)
+}
+
+// UnmarshalJSON decodes a worklog, keeping unknown properties in Extra
+func (w *Worklog) UnmarshalJSON(data []byte) error {
+	type plain Worklog
+	if err := json.Unmarshal(data, (*plain)(w)); err != nil {
+		return err
+	}
+	extra, err := splitUnknown(data, "id", "issueId", "self", "author", "updateAuthor", "comment", "started",
+		"timeSpent", "timeSpentSeconds", "visibility")
+	w.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes a worklog including the unknown properties
+func (w Worklog) MarshalJSON() ([]byte, error) {
+	type plain Worklog
+	return mergeUnknown(plain(w), w.Extra)
+}
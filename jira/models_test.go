@@ -0,0 +1,204 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// decodeTestdata decodes a response recorded in testdata
+func decodeTestdata(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decoding %s: %v", name, err)
+	}
+}
+
+// assertRoundTrip encodes v, which must keep the unknown properties, then decodes it into a new
+// value of its type and encodes that again, which must give the same JSON
+func assertRoundTrip(t *testing.T, v interface{}, unknown ...string) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("encoding %T: %v", v, err)
+	}
+	for _, key := range unknown {
+		if !bytes.Contains(data, []byte(`"`+key+`":`)) {
+			t.Errorf("encoded %T lost %s: %s", v, key, data)
+		}
+	}
+	decoded := reflect.New(reflect.TypeOf(v).Elem()).Interface()
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("decoding encoded %T: %v", v, err)
+	}
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("encoding decoded %T: %v", v, err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Errorf("%T changed on a round trip\n got %s\nwant %s", v, again, data)
+	}
+}
+
+func TestSearchPageV2(t *testing.T) {
+	var page SearchPage
+	decodeTestdata(t, "v2/search.json", &page)
+
+	if page.StartAt != 0 || page.MaxResults != 50 || page.Total != 2 || len(page.Issues) != 2 {
+		t.Fatalf("unexpected page: startAt %d, maxResults %d, total %d, %d issues", page.StartAt, page.MaxResults, page.Total, len(page.Issues))
+	}
+
+	issue := page.Issues[0]
+	if issue.ID != "10001" || issue.Key != "PROJ-124" || issue.Self == "" {
+		t.Errorf("unexpected issue %s (%s, %s)", issue.Key, issue.ID, issue.Self)
+	}
+	fields := issue.Fields
+	if fields.Summary != "Code review" || fields.IssueType == nil || fields.IssueType.Name != "Task" {
+		t.Errorf("unexpected summary %q or type %+v", fields.Summary, fields.IssueType)
+	}
+	if fields.Status == nil || fields.Status.Name != "In Progress" || fields.Project == nil || fields.Project.Key != "PROJ" {
+		t.Errorf("unexpected status %+v or project %+v", fields.Status, fields.Project)
+	}
+	if fields.EpicLink != "PROJ-100" || fields.Parent != nil {
+		t.Errorf("expected the epic link PROJ-100 and no parent, got %q and %+v", fields.EpicLink, fields.Parent)
+	}
+	if _, ok := issue.Extra["expand"]; !ok {
+		t.Errorf("expected expand to be kept in Extra, got %v", issue.Extra)
+	}
+	if _, ok := fields.Extra["customfield_10020"]; !ok || len(fields.Extra) != 1 {
+		t.Errorf("expected only the sprint field in Extra, got %v", fields.Extra)
+	}
+
+	epic := page.Issues[1]
+	if !epic.IsEpic() || epic.Fields.EpicLink != "" || epic.Fields.Extra != nil {
+		t.Errorf("expected an epic without epic link or extra fields, got %+v", epic.Fields)
+	}
+
+	assertRoundTrip(t, &page.Issues[0], "expand", "customfield_10014", "customfield_10020")
+	assertRoundTrip(t, &page.Issues[1], "expand")
+}
+
+func TestSearchPageV3(t *testing.T) {
+	var page SearchPage
+	decodeTestdata(t, "v3/search.json", &page)
+
+	if page.NextPageToken != "CAEaAggD" || page.IsLast || len(page.Issues) != 1 {
+		t.Fatalf("unexpected page: nextPageToken %q, isLast %v, %d issues", page.NextPageToken, page.IsLast, len(page.Issues))
+	}
+
+	issue := page.Issues[0]
+	if issue.Key != "PROJ-123" || issue.IsEpic() || issue.Fields.EpicLink != "" {
+		t.Errorf("unexpected issue %s, epic link %q", issue.Key, issue.Fields.EpicLink)
+	}
+	parent := issue.Fields.Parent
+	if parent == nil || parent.Key != "PROJ-100" || parent.Fields == nil {
+		t.Fatalf("expected the parent PROJ-100 with its fields, got %+v", parent)
+	}
+	if parent.Fields.Summary != "Platform improvements" || parent.Fields.IssueType == nil || parent.Fields.IssueType.Name != "Epic" {
+		t.Errorf("unexpected parent fields %+v", parent.Fields)
+	}
+	if _, ok := issue.Fields.Extra["labels"]; !ok {
+		t.Errorf("expected labels to be kept in Extra, got %v", issue.Fields.Extra)
+	}
+
+	assertRoundTrip(t, &issue, "expand", "labels")
+}
+
+func TestWorklogPages(t *testing.T) {
+	tests := []struct {
+		file        string
+		authorID    string
+		comment     string
+		visibility  *Visibility
+		authorExtra string
+		extra       []string
+	}{
+		{
+			file:        "v2/worklogs.json",
+			authorID:    "jdoe",
+			comment:     "Reviewed pull requests",
+			visibility:  &Visibility{Type: "role", Value: "Developers"},
+			authorExtra: "avatarUrls",
+			extra:       []string{"created", "updated"},
+		},
+		{
+			file:        "v3/worklogs.json",
+			authorID:    "5b10ac8d82e05b22cc7d4ef5",
+			comment:     "Reviewed pull requests\nand merged two",
+			authorExtra: "accountType",
+			extra:       []string{"created", "updated", "properties"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			var page WorklogPage
+			decodeTestdata(t, tt.file, &page)
+			if page.Total != 1 || len(page.Worklogs) != 1 {
+				t.Fatalf("expected 1 worklog, got total %d and %d worklogs", page.Total, len(page.Worklogs))
+			}
+
+			worklog := page.Worklogs[0]
+			if worklog.ID != "10000" || worklog.IssueID != "10001" || worklog.TimeSpentSeconds != 3600 || worklog.TimeSpent != "1h" {
+				t.Errorf("unexpected worklog %+v", worklog)
+			}
+			if started, err := worklog.StartedTime(); err != nil || started.Hour() != 9 {
+				t.Errorf("unexpected start %q: %v", worklog.Started, err)
+			}
+			if got := worklog.CommentText(); got != tt.comment {
+				t.Errorf("CommentText() = %q, want %q", got, tt.comment)
+			}
+			if !reflect.DeepEqual(worklog.Visibility, tt.visibility) {
+				t.Errorf("visibility = %+v, want %+v", worklog.Visibility, tt.visibility)
+			}
+
+			author := worklog.Author
+			if author.ID() != tt.authorID || !author.Matches(tt.authorID) || author.DisplayName != "Jane Doe" || !author.Active {
+				t.Errorf("unexpected author %+v", author)
+			}
+			if _, ok := author.Extra[tt.authorExtra]; !ok {
+				t.Errorf("expected %s to be kept in the author's Extra, got %v", tt.authorExtra, author.Extra)
+			}
+			if len(worklog.Extra) != len(tt.extra) {
+				t.Errorf("expected Extra to hold %v, got %v", tt.extra, worklog.Extra)
+			}
+			for _, key := range tt.extra {
+				if _, ok := worklog.Extra[key]; !ok {
+					t.Errorf("expected %s to be kept in Extra, got %v", key, worklog.Extra)
+				}
+			}
+
+			assertRoundTrip(t, &worklog, append(tt.extra, tt.authorExtra)...)
+		})
+	}
+}
+
+func TestCommentText(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment string
+		want    string
+	}{
+		{"missing", ``, ""},
+		{"v2 string", `"Fixed the build"`, "Fixed the build"},
+		{"v2 empty string", `""`, ""},
+		{"v3 document", `{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"Fixed the build"}]}]}`, "Fixed the build"},
+		{"v3 empty document", `{"type":"doc","version":1,"content":[]}`, ""},
+		{"invalid", `42`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worklog := Worklog{Comment: json.RawMessage(tt.comment)}
+			if got := worklog.CommentText(); got != tt.want {
+				t.Errorf("CommentText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// transport sends authenticated requests to one version of the REST API
+type transport struct {
+	baseURL  string
+	username string
+	token    string
+	http     *http.Client
+	logger   *slog.Logger
+}
+
+func (t *transport) debug(ctx context.Context, msg string, args ...any) {
+	if t.logger != nil {
+		t.logger.DebugContext(ctx, msg, args...)
+	}
+}
+
+// newRequestID returns a random id correlating the log lines of a request. It is also sent to
+// Jira as X-Request-Id.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// do sends a request and returns the response body. apiPath is relative to /rest/api/{version},
+// e.g. "/myself". Status codes other than the expected ones are returned as an *APIError.
+func (t *transport) do(ctx context.Context, method, apiPath string, payload interface{}, expected ...int) ([]byte, error) {
+	var body io.Reader
+	var payloadBytes []byte
+	if payload != nil {
+		var err error
+		if payloadBytes, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		body = bytes.NewReader(payloadBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.baseURL+apiPath, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	auth := base64.StdEncoding.EncodeToString([]byte(t.username + ":" + t.token))
+	req.Header.Set("Authorization", "Basic "+auth)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	requestID := newRequestID()
+	req.Header.Set("X-Request-Id", requestID)
+
+	if payloadBytes != nil {
+		t.debug(ctx, "request", "request_id", requestID, "method", method, "url", req.URL.String(), "payload", string(payloadBytes))
+	} else {
+		t.debug(ctx, "request", "request_id", requestID, "method", method, "url", req.URL.String())
+	}
+
+	start := time.Now()
+	resp, err := t.http.Do(req)
+	if err != nil {
+		t.debug(ctx, "request failed", "request_id", requestID, "duration_ms", time.Since(start).Milliseconds(), "error", err.Error())
+		return nil, &RequestError{Method: method, Path: apiPath, Err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.debug(ctx, "request failed", "request_id", requestID, "duration_ms", time.Since(start).Milliseconds(), "error", err.Error())
+		return nil, &RequestError{Method: method, Path: apiPath, Err: err}
+	}
+
+	// Jira's own id of the request, quoted by Atlassian support
+	attrs := []any{"request_id", requestID, "status", resp.StatusCode, "duration_ms", time.Since(start).Milliseconds()}
+	if jiraID := resp.Header.Get("X-Arequestid"); jiraID != "" {
+		attrs = append(attrs, "jira_request_id", jiraID)
+	}
+	t.debug(ctx, "response", attrs...)
+
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return respBody, nil
+		}
+	}
+	return nil, newAPIError(method, apiPath, resp.StatusCode, respBody)
+}
+
+// decode sends a request expecting 200 or 201 and decodes the response into v
+func (t *transport) decode(ctx context.Context, method, apiPath string, payload, v interface{}) error {
+	body, err := t.do(ctx, method, apiPath, payload, 200, 201)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
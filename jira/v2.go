@@ -0,0 +1,48 @@
+package jira
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// v2Client talks to the Jira Server/Data Center REST API v2
+type v2Client struct {
+	base
+}
+
+// SearchIssues runs a JQL query with the search endpoint, paging with startAt
+func (c *v2Client) SearchIssues(ctx context.Context, jql string, fields []string) ([]Issue, error) {
+	issues := []Issue{}
+	startAt := 0
+	for {
+		queryParams := url.Values{
+			"jql":        {jql},
+			"fields":     {strings.Join(fields, ",")},
+			"maxResults": {"100"},
+			"startAt":    {strconv.Itoa(startAt)},
+		}
+
+		var page SearchPage
+		if err := c.t.decode(ctx, "GET", "/search?"+queryParams.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		issues = append(issues, page.Issues...)
+
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			return issues, nil
+		}
+	}
+}
+
+// AddWorklog adds a worklog with a plain text comment
+func (c *v2Client) AddWorklog(ctx context.Context, issueKey string, worklog NewWorklog) (*Worklog, error) {
+	return c.addWorklog(ctx, issueKey, worklogPayload(worklog, worklog.Comment))
+}
+
+// UpdateWorklog updates a worklog with a plain text comment
+func (c *v2Client) UpdateWorklog(ctx context.Context, issueKey, worklogID string, worklog NewWorklog) (*Worklog, error) {
+	return c.updateWorklog(ctx, issueKey, worklogID, worklogPayload(worklog, worklog.Comment))
+}
@@ -0,0 +1,57 @@
+package jira
+
+import "context"
+
+// v3Client talks to the Jira Cloud REST API v3
+type v3Client struct {
+	base
+}
+
+// SearchIssues runs a JQL query with the search/jql endpoint, paging with nextPageToken
+func (c *v3Client) SearchIssues(ctx context.Context, jql string, fields []string) ([]Issue, error) {
+	issues := []Issue{}
+	nextPageToken := ""
+	for {
+		requestBody := map[string]interface{}{
+			"jql":        jql,
+			"fields":     fields,
+			"maxResults": 100,
+		}
+		if nextPageToken != "" {
+			requestBody["nextPageToken"] = nextPageToken
+		}
+
+		var page SearchPage
+		if err := c.t.decode(ctx, "POST", "/search/jql", requestBody, &page); err != nil {
+			return nil, err
+		}
+		issues = append(issues, page.Issues...)
+
+		if page.IsLast || page.NextPageToken == "" {
+			return issues, nil
+		}
+		nextPageToken = page.NextPageToken
+	}
+}
+
+// AddWorklog adds a worklog with the comment in Atlassian Document Format
+func (c *v3Client) AddWorklog(ctx context.Context, issueKey string, worklog NewWorklog) (*Worklog, error) {
+	return c.addWorklog(ctx, issueKey, worklogPayload(worklog, adfDocument(worklog.Comment)))
+}
+
+// UpdateWorklog updates a worklog with the comment in Atlassian Document Format
+func (c *v3Client) UpdateWorklog(ctx context.Context, issueKey, worklogID string, worklog NewWorklog) (*Worklog, error) {
+	return c.updateWorklog(ctx, issueKey, worklogID, worklogPayload(worklog, adfDocument(worklog.Comment)))
+}
+
+// adfDocument wraps plain text in a single paragraph Atlassian Document Format document
+func adfDocument(text string) adfNode {
+	return adfNode{
+		Type:    "doc",
+		Version: 1,
+		Content: []adfNode{{
+			Type:    "paragraph",
+			Content: []adfNode{{Type: "text", Text: text}},
+		}},
+	}
+}
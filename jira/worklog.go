@@ -0,0 +1,93 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// base implements the endpoints that are the same in both API versions
+type base struct {
+	t *transport
+}
+
+// Myself returns the authenticated user
+func (c *base) Myself(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.t.decode(ctx, "GET", "/myself", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetWorklogs returns the worklogs of an issue started after the given time, following pagination
+func (c *base) GetWorklogs(ctx context.Context, issueKey string, startedAfter time.Time) ([]Worklog, error) {
+	worklogs := []Worklog{}
+	startAt := 0
+	for {
+		queryParams := url.Values{
+			"startAt":    {strconv.Itoa(startAt)},
+			"maxResults": {"1000"},
+		}
+		if !startedAfter.IsZero() {
+			queryParams.Set("startedAfter", strconv.FormatInt(startedAfter.UnixMilli(), 10))
+		}
+
+		var page WorklogPage
+		path := fmt.Sprintf("/issue/%s/worklog?%s", url.PathEscape(issueKey), queryParams.Encode())
+		if err := c.t.decode(ctx, "GET", path, nil, &page); err != nil {
+			return nil, err
+		}
+		worklogs = append(worklogs, page.Worklogs...)
+
+		startAt += len(page.Worklogs)
+		if len(page.Worklogs) == 0 || startAt >= page.Total {
+			return worklogs, nil
+		}
+	}
+}
+
+// DeleteWorklog deletes a worklog of an issue
+func (c *base) DeleteWorklog(ctx context.Context, issueKey, worklogID string) error {
+	path := fmt.Sprintf("/issue/%s/worklog/%s", url.PathEscape(issueKey), url.PathEscape(worklogID))
+	_, err := c.t.do(ctx, "DELETE", path, nil, 200, 204)
+	return err
+}
+
+// addWorklog posts a worklog payload built by the version specific client
+func (c *base) addWorklog(ctx context.Context, issueKey string, payload map[string]interface{}) (*Worklog, error) {
+	var created Worklog
+	path := fmt.Sprintf("/issue/%s/worklog", url.PathEscape(issueKey))
+	if err := c.t.decode(ctx, "POST", path, payload, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// updateWorklog puts a worklog payload built by the version specific client
+func (c *base) updateWorklog(ctx context.Context, issueKey, worklogID string, payload map[string]interface{}) (*Worklog, error) {
+	var updated Worklog
+	path := fmt.Sprintf("/issue/%s/worklog/%s", url.PathEscape(issueKey), url.PathEscape(worklogID))
+	if err := c.t.decode(ctx, "PUT", path, payload, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// worklogPayload builds the request body of a worklog with the comment in the given representation
+func worklogPayload(worklog NewWorklog, comment interface{}) map[string]interface{} {
+	payload := map[string]interface{}{
+		"started":          worklog.Started,
+		"timeSpentSeconds": worklog.TimeSpentSeconds,
+	}
+	// Comments are only included when the worklog has one
+	if worklog.Comment != "" {
+		payload["comment"] = comment
+	}
+	if worklog.Visibility != nil {
+		payload["visibility"] = worklog.Visibility
+	}
+	return payload
+}
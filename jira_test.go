@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/liam-witterick/jira-worklogger/mockjira"
+)
+
+func TestPostWorklogFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int // Status of the injected failure, 0 for none
+		code      int
+		kind      string
+		retryable bool
+		exitCode  int
+	}{
+		{name: "bad request", status: 400, code: 400, kind: FailureValidation, exitCode: ExitValidation},
+		{name: "unauthorized", status: 401, code: 401, kind: FailureAuth, exitCode: ExitAuth},
+		{name: "forbidden", status: 403, code: 403, kind: FailureAuth, exitCode: ExitAuth},
+		{name: "not found", status: 404, code: 404, kind: FailureValidation, exitCode: ExitValidation},
+		{name: "timeout", status: 408, code: 408, kind: FailureNetwork, retryable: true, exitCode: ExitNetwork},
+		{name: "rate limited", status: 429, code: 429, kind: FailureRateLimit, retryable: true, exitCode: ExitFailure},
+		{name: "server error", status: 500, code: 500, kind: FailureServer, retryable: true, exitCode: ExitFailure},
+		{name: "unavailable", status: 503, code: 503, kind: FailureServer, retryable: true, exitCode: ExitFailure},
+		{name: "unreachable", code: 0, kind: FailureNetwork, retryable: true, exitCode: ExitNetwork},
+	}
+
+	entry := TimeEntry{Date: "2025-09-09", Issue: "OPS-7", Seconds: 3600, Started: "2025-09-09T17:00:00.000+0100"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := []mockjira.Failure{}
+			if tt.status != 0 {
+				failures = append(failures, mockjira.Failure{Method: "POST", Path: "/issue/OPS-7/worklog", Status: tt.status})
+			}
+			settings := newTestSettings(t, "", failures...)
+			if tt.status == 0 {
+				settings.JiraBaseURL = "http://127.0.0.1:1"
+			}
+
+			result := PostWorklog(settings, NewLogger("error"), entry)
+			if result.Success || result.Code != tt.code || result.Message == "" {
+				t.Fatalf("expected a failure with code %d and a message, got %+v", tt.code, result)
+			}
+			failed := NewFailedEntry(entry, result)
+			if failed.Kind != tt.kind || failed.Retryable != tt.retryable {
+				t.Errorf("classified as %s (retryable %v), want %s (retryable %v)", failed.Kind, failed.Retryable, tt.kind, tt.retryable)
+			}
+			if exitCode := ExitCodeForFailures(0, []FailedEntry{failed}); exitCode != tt.exitCode {
+				t.Errorf("exit code %d, want %d", exitCode, tt.exitCode)
+			}
+			if exitCode := ExitCodeForFailures(1, []FailedEntry{failed}); exitCode != ExitPartial {
+				t.Errorf("exit code %d after posting a worklog, want %d", exitCode, ExitPartial)
+			}
+		})
+	}
+
+	t.Run("posted", func(t *testing.T) {
+		result := PostWorklog(newTestSettings(t, ""), NewLogger("error"), entry)
+		if !result.Success || result.ID == "" {
+			t.Errorf("expected the worklog to be posted, got %+v", result)
+		}
+	})
+}
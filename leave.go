@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// leaveStart is the local start time of leave worklogs
+const leaveStart = "09:00"
+
+// runLeave logs a full day of absence per working day of a range to the issue of a leave type.
+// Weekends and holidays are skipped, and days that already have time logged are refused.
+func runLeave(args []string) int {
+	usage := func() int {
+		fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger leave RANGE [--type TYPE] [--skip-logged] [--yes]")
+		return ExitConfig
+	}
+	spec := ""
+	leaveType := ""
+	assumeYes := false
+	skipLogged := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--type" && i+1 < len(args):
+			leaveType = strings.ToLower(args[i+1])
+			i++
+		case args[i] == "--yes" || args[i] == "-y":
+			assumeYes = true
+		case args[i] == "--skip-logged":
+			skipLogged = true
+		case !strings.HasPrefix(args[i], "-") && spec == "":
+			spec = args[i]
+		default:
+			return usage()
+		}
+	}
+	if spec == "" {
+		return usage()
+	}
+	dates, err := ParseDateRange(spec, DefaultDateStr())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return ExitConfig
+	}
+
+	settings, logger := setup()
+
+	leaveIssues := map[string]string{}
+	types := make([]string, 0, len(settings.Leave))
+	for name, target := range settings.Leave {
+		leaveIssues[strings.ToLower(name)] = target
+		types = append(types, strings.ToLower(name))
+	}
+	sort.Strings(types)
+	if leaveType == "" && len(types) == 1 {
+		leaveType = types[0]
+	}
+	target, ok := leaveIssues[leaveType]
+	switch {
+	case len(types) == 0:
+		logger.Error("No leave types configured, map them to issues in the leave section of the config")
+		return ExitConfig
+	case leaveType == "":
+		logger.Error("Pass --type, one of: %s", strings.Join(types, ", "))
+		return ExitConfig
+	case !ok:
+		logger.Error("Unknown leave type %s, expected one of: %s", leaveType, strings.Join(types, ", "))
+		return ExitConfig
+	}
+	issue := ResolveAlias(target, settings.CategoryAliases, logger)
+	alias := ""
+	if issue != target {
+		alias = target
+	}
+	label := strings.ToUpper(leaveType[:1]) + leaveType[1:]
+
+	// Time already logged refuses a day, leave is a whole day or nothing
+	existing, err := GetMyWorklogs(settings, logger, dates.From.Format("2006-01-02"), dates.To.Format("2006-01-02"))
+	if err != nil {
+		logger.Error("Failed to load existing worklogs: %v", err)
+		return ExitCodeFor(err)
+	}
+	logged := map[string]int{}
+	for _, worklog := range existing {
+		logged[worklog.Date] += worklog.Seconds
+	}
+
+	calendar := settings.WorkCalendar()
+	entries := []TimeEntry{}
+	refused := []string{}
+	for _, dateStr := range dates.Days() {
+		date, _ := ParseDate(dateStr)
+		seconds := calendar.ScheduledSeconds(date)
+		if seconds == 0 {
+			logger.Debug("Skipping %s, %s", dateStr, calendar.DescribeDayOff(date))
+			continue
+		}
+		if logged[dateStr] > 0 {
+			refused = append(refused, fmt.Sprintf("%s (%s logged)", dateStr, FormatSeconds(logged[dateStr])))
+			continue
+		}
+		entries = append(entries, TimeEntry{
+			Date:    dateStr,
+			Issue:   issue,
+			Alias:   alias,
+			Seconds: seconds,
+			Start:   leaveStart,
+			Comment: label + " leave",
+		})
+	}
+
+	if len(refused) > 0 {
+		if !skipLogged {
+			logger.Error("Days that already have time logged can't take leave: %s", strings.Join(refused, ", "))
+			logger.Error("Pass --skip-logged to log leave on the other days only.")
+			return ExitValidation
+		}
+		logger.Warn("Skipping days that already have time logged: %s", strings.Join(refused, ", "))
+	}
+	if len(entries) == 0 {
+		logger.Info("No working days to log leave on between %s and %s.", dates.From.Format("2006-01-02"), dates.To.Format("2006-01-02"))
+		return ExitOK
+	}
+
+	problems, err := ValidateIssues(settings, logger, []string{issue})
+	if err != nil {
+		logger.Error("Failed to validate issues: %v", err)
+		return ExitCodeFor(err)
+	}
+	if problem, ok := problems[strings.ToUpper(issue)]; ok {
+		logger.Error("Can't log %s leave to %s: %s", leaveType, problem.Issue, problem.Reason)
+		return ExitValidation
+	}
+
+	total := 0
+	fmt.Printf("%s leave on %s:\n", label, issue)
+	for _, entry := range entries {
+		date, _ := ParseDate(entry.Date)
+		fmt.Printf("  %s %s  %s\n", entry.Date, date.Format("Mon"), FormatSeconds(entry.Seconds))
+		total += entry.Seconds
+	}
+	fmt.Printf("Total %s over %d day(s)\n", FormatSeconds(total), len(entries))
+	if !assumeYes {
+		if !StdinIsTerminal() {
+			logger.Error("Refusing to post leave without confirmation, pass --yes")
+			return ExitConfig
+		}
+		fmt.Printf("\nPost %d worklog(s) to %s? [y/N]: ", len(entries), issue)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			logger.Info("Aborted, nothing was posted.")
+			return ExitFailure
+		}
+	}
+
+	days := GroupEntriesByDate(entries)
+	if err := ResolveStartedISO(days, settings.Timezone, logger); err != nil {
+		logger.Error("%v", err)
+		return ExitValidation
+	}
+
+	submission := NewSubmissionID()
+	input := fmt.Sprintf("leave %s --type %s", spec, leaveType)
+	failedRun := FailedRun{Submission: submission, Timestamp: time.Now(), Profile: settings.Profile()}
+	posted := 0
+	for _, day := range days {
+		for _, entry := range day.Entries {
+			result := PostWorklog(settings, logger, entry)
+			if result.Success {
+				if posted == 0 {
+					RecordSubmission(settings, logger, submission, input)
+				}
+				RecordPosted(settings, logger, submission, result)
+				posted++
+				logger.Info("  - %s %s: %s", entry.Date, entry.Issue, FormatSeconds(entry.Seconds))
+			} else {
+				failed := NewFailedEntry(entry, result)
+				failedRun.Entries = append(failedRun.Entries, failed)
+				logger.Error("  - %s %s: %s\n    %s", entry.Date, entry.Issue, describeFailure(failed), failed.Reason)
+			}
+		}
+	}
+
+	// Replace the failures of the previous run, so retry never re-posts what has been posted since
+	if err := SaveFailedRun(failedRun); err != nil {
+		logger.Warn("Failed to save failed entries for retry: %v", err)
+	} else if len(failedRun.Entries) > 0 {
+		retryable := 0
+		for _, failed := range failedRun.Entries {
+			if failed.Retryable {
+				retryable++
+			}
+		}
+		if retryable > 0 {
+			logger.Info("Run 'jira-worklogger retry' to re-post the %d retryable days.", retryable)
+		} else {
+			logger.Info("None of the failures is retryable, fix their cause and run 'jira-worklogger retry --all' to re-post them.")
+		}
+	}
+	logger.Info("Posted %d leave worklog(s) as submission %s.", posted, submission)
+	return ExitCodeForFailures(posted, failedRun.Entries)
+}
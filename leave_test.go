@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/liam-witterick/jira-worklogger/mockjira"
+)
+
+// leaveConfig maps two leave types, one through an alias, with a holiday and PTO in 2025-W37
+const leaveConfig = `defaults:
+  daily_target: 7.5h
+  category_aliases:
+    absence: OPS-7
+calendar:
+  targets:
+    fri: 4h
+  holidays: [2025-09-10]
+  pto: [2025-09-11]
+leave:
+  annual: absence
+  Sick: OPS-7
+`
+
+func TestRunLeaveRefusals(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		want   int
+	}{
+		{name: "no range", config: leaveConfig, args: []string{"--type", "annual"}, want: ExitConfig},
+		{name: "invalid range", config: leaveConfig, args: []string{"next-year", "--type", "annual"}, want: ExitConfig},
+		{name: "unknown option", config: leaveConfig, args: []string{"2025-W37", "--force"}, want: ExitConfig},
+		{name: "no leave types", config: "defaults:\n  daily_target: 7.5h\n", args: []string{"2025-09-09", "--yes"}, want: ExitConfig},
+		{name: "ambiguous type", config: leaveConfig, args: []string{"2025-09-09", "--yes"}, want: ExitConfig},
+		{name: "unknown type", config: leaveConfig, args: []string{"2025-09-09", "--type", "parental", "--yes"}, want: ExitConfig},
+		{name: "day with time logged", config: leaveConfig, args: []string{"2025-W37", "--type", "annual", "--yes"}, want: ExitValidation},
+		{name: "no confirmation", config: leaveConfig, args: []string{"2025-09-09", "--type", "sick"}, want: ExitConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t, tt.config)
+			withStdin(t, "y\n") // Not a terminal, so never a confirmation
+			captureStdout(t, func() {
+				if code := runLeave(tt.args); code != tt.want {
+					t.Errorf("runLeave(%q) exited with %d, want %d", tt.args, code, tt.want)
+				}
+			})
+			if posted := leaveWorklogs(t, settings); len(posted) != 0 {
+				t.Errorf("expected nothing posted, got %v", posted)
+			}
+		})
+	}
+}
+
+func TestRunLeave(t *testing.T) {
+	settings := newTestSettings(t, leaveConfig)
+	stale := FailedRun{Submission: "stale", Entries: []FailedEntry{{Entry: TimeEntry{Date: "2025-09-09", Issue: "OPS-7", Seconds: 27000}}}}
+	if err := SaveFailedRun(stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// Monday has time logged, Wednesday is a holiday and the weekend is not worked. PTO is
+	// still a working day, leave is how it gets logged.
+	captureStdout(t, func() {
+		if code := runLeave([]string{"2025-W37", "--type", "Annual", "--skip-logged", "--yes"}); code != ExitOK {
+			t.Errorf("runLeave exited with %d, want %d", code, ExitOK)
+		}
+	})
+	want := []string{"2025-09-09 27000", "2025-09-11 27000", "2025-09-12 14400"}
+	if got := leaveWorklogs(t, settings); !reflect.DeepEqual(got, want) {
+		t.Errorf("leave worklogs\n got %v\nwant %v", got, want)
+	}
+
+	// The failures of the previous run were replaced, retry must not post Tuesday again
+	if run, err := LoadFailedRun(); run != nil || err != nil {
+		t.Errorf("expected the saved failures to be cleared, got %+v, %v", run, err)
+	}
+
+	records, err := ReadLedger()
+	if err != nil {
+		t.Fatalf("ReadLedger: %v", err)
+	}
+	submissions := LedgerSubmissions(records)
+	if len(submissions) != 1 || len(submissions[0].Posted) != 3 {
+		t.Fatalf("expected one submission of 3 worklogs in the ledger, got %+v", submissions)
+	}
+	if submissions[0].Input != "leave 2025-W37 --type annual" || submissions[0].Posted[0].Issue != "OPS-7" {
+		t.Errorf("unexpected submission %+v", submissions[0])
+	}
+
+	// The week is now logged, nothing is left to take
+	captureStdout(t, func() {
+		if code := runLeave([]string{"2025-W37", "--type", "sick", "--skip-logged", "--yes"}); code != ExitOK {
+			t.Errorf("second runLeave exited with %d, want %d", code, ExitOK)
+		}
+	})
+	if got := leaveWorklogs(t, settings); len(got) != 3 {
+		t.Errorf("expected no more leave, got %v", got)
+	}
+}
+
+// leaveWorklogs lists the worklogs of OPS-7 in 2025-W37 as "date seconds"
+func leaveWorklogs(t *testing.T, settings *Settings) []string {
+	t.Helper()
+	worklogs, err := GetMyWorklogs(settings, NewLogger("error"), "2025-09-08", "2025-09-14")
+	if err != nil {
+		t.Fatalf("GetMyWorklogs: %v", err)
+	}
+	got := []string{}
+	for _, worklog := range worklogs {
+		if worklog.Issue == "OPS-7" {
+			got = append(got, fmt.Sprintf("%s %d", worklog.Date, worklog.Seconds))
+		}
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestRunLeaveFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   int
+		hint   string
+	}{
+		{name: "retryable", status: 503, want: ExitFailure, hint: "re-post the 1 retryable days"},
+		{name: "not retryable", status: 400, want: ExitValidation, hint: "None of the failures is retryable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t, leaveConfig, mockjira.Failure{Method: "POST", Path: "/issue/*/worklog", Status: tt.status, Count: 1})
+			t.Setenv("LOG_LEVEL", "info") // The hint is logged at info
+			console, _ := captureLogs(t, settings)
+			captureStdout(t, func() {
+				if code := runLeave([]string{"2025-09-12", "--type", "sick", "--yes"}); code != tt.want {
+					t.Errorf("runLeave exited with %d, want %d", code, tt.want)
+				}
+			})
+			if !strings.Contains(console.String(), tt.hint) {
+				t.Errorf("expected %q in the log:\n%s", tt.hint, console)
+			}
+			if run, err := LoadFailedRun(); err != nil || run == nil || len(run.Entries) != 1 {
+				t.Errorf("expected the failed day saved for retry, got %+v, %v", run, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ledgerFile is the name of the journal of posted worklogs in the state directory
+const ledgerFile = "ledger.jsonl"
+
+// Ledger actions
+const (
+	LedgerSubmit = "submit" // Header of a submission, written once before its first worklog
+	LedgerPost   = "post"
+	LedgerDelete = "delete"
+)
+
+// LedgerRecord is one line of the append-only journal of worklogs posted and deleted by the tool
+type LedgerRecord struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Submission string    `json:"submission"` // Shared by every worklog posted in one run
+	Action     string    `json:"action"`
+	Profile    string    `json:"profile"`
+	Issue      string    `json:"issue,omitempty"`
+	WorklogID  string    `json:"worklog_id,omitempty"`
+	Seconds    int       `json:"seconds,omitempty"`
+	Started    string    `json:"started,omitempty"`
+	Input      string    `json:"input,omitempty"` // Raw input, on the submission header
+}
+
+// Submission groups the ledger records of one run
+type Submission struct {
+	ID        string
+	Timestamp time.Time
+	Profile   string
+	Input     string
+	Posted    []LedgerRecord
+	Deleted   map[string]bool // Worklog IDs deleted since
+}
+
+// Active returns the posted worklogs that haven't been deleted
+func (s *Submission) Active() []LedgerRecord {
+	active := []LedgerRecord{}
+	for _, record := range s.Posted {
+		if !s.Deleted[record.WorklogID] {
+			active = append(active, record)
+		}
+	}
+	return active
+}
+
+// NewSubmissionID returns an identifier for the worklogs posted in one run
+func NewSubmissionID() string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%x", time.Now().Format("20060102-150405"), suffix)
+}
+
+// AppendLedger appends records to the ledger
+func AppendLedger(records ...LedgerRecord) error {
+	path, err := StatePath(ledgerFile)
+	if err != nil {
+		return err
+	}
+	unlock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %v", err)
+	}
+	defer f.Close()
+
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode ledger record: %v", err)
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write ledger: %v", err)
+		}
+	}
+	return nil
+}
+
+// RecordSubmission appends the header of a submission with its raw input to the ledger, warning
+// when that fails. It is written once, before the first worklog of the submission is recorded.
+func RecordSubmission(settings *Settings, logger *Logger, submission, input string) {
+	err := AppendLedger(LedgerRecord{
+		Timestamp:  time.Now(),
+		Submission: submission,
+		Action:     LedgerSubmit,
+		Profile:    settings.Profile(),
+		Input:      input,
+	})
+	if err != nil {
+		logger.Warn("Failed to record submission %s in the ledger: %v", submission, err)
+	}
+}
+
+// RecordPosted appends a successfully posted worklog to the ledger, warning when that fails
+func RecordPosted(settings *Settings, logger *Logger, submission string, result WorklogResult) {
+	err := AppendLedger(LedgerRecord{
+		Timestamp:  time.Now(),
+		Submission: submission,
+		Action:     LedgerPost,
+		Profile:    settings.Profile(),
+		Issue:      result.Issue,
+		WorklogID:  result.ID,
+		Seconds:    result.Seconds,
+		Started:    result.Started,
+	})
+	if err != nil {
+		logger.Warn("Failed to record worklog %s on %s in the ledger: %v", result.ID, result.Issue, err)
+	}
+}
+
+// RecordDeleted appends the deletion of a posted worklog to the ledger, warning when that fails
+func RecordDeleted(logger *Logger, posted LedgerRecord) {
+	deletion := posted
+	deletion.Timestamp = time.Now()
+	deletion.Action = LedgerDelete
+	deletion.Input = ""
+	if err := AppendLedger(deletion); err != nil {
+		logger.Warn("Failed to record deletion of worklog %s in the ledger: %v", posted.WorklogID, err)
+	}
+}
+
+// RollbackWorklogs deletes worklogs posted earlier in a run, recording each deletion in the ledger.
+// It returns the worklogs that could not be deleted.
+func RollbackWorklogs(settings *Settings, logger *Logger, submission string, results []WorklogResult) []WorklogResult {
+	orphans := []WorklogResult{}
+	for _, result := range results {
+		if result.ID == "" {
+			logger.Error("  - Can't roll back %s on %s (%s), Jira didn't return a worklog ID", result.Issue, result.Date, FormatSeconds(result.Seconds))
+			orphans = append(orphans, result)
+			continue
+		}
+		if err := DeleteWorklog(settings, logger, result.Issue, result.ID); err != nil {
+			logger.Error("  - Failed to roll back %s worklog %s: %v", result.Issue, result.ID, err)
+			orphans = append(orphans, result)
+			continue
+		}
+
+		logger.Info("  - Rolled back %s worklog %s (%s on %s)", result.Issue, result.ID, FormatSeconds(result.Seconds), result.Date)
+		RecordDeleted(logger, LedgerRecord{
+			Submission: submission,
+			Profile:    settings.Profile(),
+			Issue:      result.Issue,
+			WorklogID:  result.ID,
+			Seconds:    result.Seconds,
+			Started:    result.Started,
+		})
+	}
+	return orphans
+}
+
+// ReadLedger reads every ledger record, oldest first
+func ReadLedger() ([]LedgerRecord, error) {
+	path, err := StatePath(ledgerFile)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []LedgerRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %v", err)
+	}
+	defer f.Close()
+
+	records := []LedgerRecord{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record LedgerRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("failed to parse ledger line %d: %v", lineNo, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %v", err)
+	}
+	return records, nil
+}
+
+// LedgerSubmissions groups ledger records into submissions, oldest first
+func LedgerSubmissions(records []LedgerRecord) []*Submission {
+	submissions := []*Submission{}
+	index := map[string]*Submission{}
+
+	for _, record := range records {
+		submission, ok := index[record.Submission]
+		if !ok {
+			submission = &Submission{
+				ID:        record.Submission,
+				Timestamp: record.Timestamp,
+				Profile:   record.Profile,
+				Deleted:   map[string]bool{},
+			}
+			index[record.Submission] = submission
+			submissions = append(submissions, submission)
+		}
+
+		switch record.Action {
+		case LedgerSubmit:
+			submission.Input = record.Input
+		case LedgerPost:
+			submission.Posted = append(submission.Posted, record)
+			if submission.Input == "" {
+				submission.Input = record.Input // Ledgers written before submission headers
+			}
+		case LedgerDelete:
+			submission.Deleted[record.WorklogID] = true
+		}
+	}
+	return submissions
+}
+
+// runHistory lists recent submissions, or the worklogs of one submission
+func runHistory(args []string) int {
+	limit := 10
+	submissionID := ""
+	outputFormat := OutputTable
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--output" && i+1 < len(args):
+			format, err := ParseOutputFormat(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+				return ExitConfig
+			}
+			outputFormat = format
+			i++
+		case args[i] == "--limit" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "[error] Invalid --limit %s\n", args[i+1])
+				return ExitConfig
+			}
+			limit = n
+			i++
+		case !strings.HasPrefix(args[i], "--") && submissionID == "":
+			submissionID = args[i]
+		default:
+			fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger history [SUBMISSION] [--limit N] [--output json|yaml|table]")
+			return ExitConfig
+		}
+	}
+
+	records, err := ReadLedger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return 1
+	}
+	submissions := LedgerSubmissions(records)
+
+	if submissionID != "" {
+		for _, submission := range submissions {
+			if submission.ID == submissionID {
+				if outputFormat != OutputTable {
+					return writeHistory(outputFormat, historyEntry(submission, true))
+				}
+				printSubmission(submission)
+				return 0
+			}
+		}
+		fmt.Fprintf(os.Stderr, "[error] Submission %s not found in the ledger\n", submissionID)
+		return 1
+	}
+
+	start := len(submissions) - limit
+	if start < 0 {
+		start = 0
+	}
+	if outputFormat != OutputTable {
+		entries := []HistoryEntry{}
+		for i := len(submissions) - 1; i >= start; i-- {
+			entries = append(entries, historyEntry(submissions[i], false))
+		}
+		return writeHistory(outputFormat, entries)
+	}
+
+	if len(submissions) == 0 {
+		fmt.Println("Nothing has been posted yet.")
+		return 0
+	}
+
+	fmt.Printf("%-20s  %-19s  %-8s  %-9s  %s\n", "SUBMISSION", "TIME", "WORKLOGS", "TOTAL", "PROFILE")
+	for i := len(submissions) - 1; i >= start; i-- {
+		submission := submissions[i]
+		total := 0
+		for _, record := range submission.Posted {
+			total += record.Seconds
+		}
+		count := fmt.Sprintf("%d", len(submission.Posted))
+		if active := len(submission.Active()); active != len(submission.Posted) {
+			count = fmt.Sprintf("%d/%d", active, len(submission.Posted))
+		}
+		fmt.Printf("%-20s  %-19s  %-8s  %-9s  %s\n", submission.ID, submission.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			count, FormatSeconds(total), submission.Profile)
+	}
+	return 0
+}
+
+// HistoryEntry is a submission in machine-readable history output
+type HistoryEntry struct {
+	Submission   string           `json:"submission" yaml:"submission"`
+	Timestamp    time.Time        `json:"timestamp" yaml:"timestamp"`
+	Profile      string           `json:"profile" yaml:"profile"`
+	TotalSeconds int              `json:"total_seconds" yaml:"total_seconds"`
+	Worklogs     []HistoryWorklog `json:"worklogs" yaml:"worklogs"`
+	Input        string           `json:"input,omitempty" yaml:"input,omitempty"`
+}
+
+// HistoryWorklog is a worklog of a submission in machine-readable history output
+type HistoryWorklog struct {
+	Issue     string `json:"issue" yaml:"issue"`
+	WorklogID string `json:"worklog_id" yaml:"worklog_id"`
+	Seconds   int    `json:"seconds" yaml:"seconds"`
+	Started   string `json:"started" yaml:"started"`
+	Undone    bool   `json:"undone" yaml:"undone"`
+}
+
+// historyEntry converts a submission for machine-readable output, with its raw input if asked
+func historyEntry(submission *Submission, withInput bool) HistoryEntry {
+	entry := HistoryEntry{
+		Submission: submission.ID,
+		Timestamp:  submission.Timestamp,
+		Profile:    submission.Profile,
+		Worklogs:   []HistoryWorklog{},
+	}
+	for _, record := range submission.Posted {
+		entry.TotalSeconds += record.Seconds
+		entry.Worklogs = append(entry.Worklogs, HistoryWorklog{
+			Issue:     record.Issue,
+			WorklogID: record.WorklogID,
+			Seconds:   record.Seconds,
+			Started:   record.Started,
+			Undone:    submission.Deleted[record.WorklogID],
+		})
+	}
+	if withInput {
+		entry.Input = submission.Input
+	}
+	return entry
+}
+
+// writeHistory writes history output and returns the exit code
+func writeHistory(format string, v interface{}) int {
+	if err := WriteOutput(format, v); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// printSubmission prints the worklogs and raw input of a submission
+func printSubmission(submission *Submission) {
+	fmt.Printf("Submission %s (%s, %s)\n", submission.ID, submission.Timestamp.Local().Format("2006-01-02 15:04:05"), submission.Profile)
+	for _, record := range submission.Posted {
+		status := ""
+		if submission.Deleted[record.WorklogID] {
+			status = "  (undone)"
+		}
+		fmt.Printf("  %-12s %-10s %-8s %s%s\n", record.Issue, record.WorklogID, FormatSeconds(record.Seconds), record.Started, status)
+	}
+	if submission.Input != "" {
+		fmt.Printf("\nInput:\n%s\n", strings.TrimRight(submission.Input, "\n"))
+	}
+}
+
+// runUndo deletes the worklogs of the last submission, or of a chosen one, from Jira
+func runUndo(args []string) int {
+	submissionID := ""
+	assumeYes := false
+	for _, arg := range args {
+		switch {
+		case arg == "--yes" || arg == "-y":
+			assumeYes = true
+		case !strings.HasPrefix(arg, "-") && submissionID == "":
+			submissionID = arg
+		default:
+			fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger undo [SUBMISSION] [--yes]")
+			return ExitConfig
+		}
+	}
+
+	settings, logger := setup()
+
+	records, err := ReadLedger()
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+
+	// Pick the requested submission, or the most recent one of this profile with worklogs left
+	var target *Submission
+	submissions := LedgerSubmissions(records)
+	for i := len(submissions) - 1; i >= 0; i-- {
+		submission := submissions[i]
+		if submissionID != "" {
+			if submission.ID == submissionID {
+				target = submission
+				break
+			}
+		} else if submission.Profile == settings.Profile() && len(submission.Active()) > 0 {
+			target = submission
+			break
+		}
+	}
+	if target == nil {
+		if submissionID != "" {
+			logger.Error("Submission %s not found in the ledger", submissionID)
+			return 1
+		}
+		logger.Info("Nothing to undo.")
+		return 0
+	}
+	if target.Profile != settings.Profile() {
+		logger.Error("Submission %s was posted as %s, but the current profile is %s", target.ID, target.Profile, settings.Profile())
+		return 1
+	}
+
+	active := target.Active()
+	if len(active) == 0 {
+		logger.Info("Submission %s has already been undone.", target.ID)
+		return 0
+	}
+
+	printSubmission(target)
+	if !assumeYes {
+		if !StdinIsTerminal() {
+			logger.Error("Refusing to delete worklogs without confirmation, pass --yes")
+			return 1
+		}
+		fmt.Printf("\nDelete %d worklog(s) from Jira? [y/N]: ", len(active))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			logger.Info("Aborted.")
+			return 1
+		}
+	}
+
+	failed := 0
+	exitCode := ExitOK
+	for _, record := range active {
+		if err := DeleteWorklog(settings, logger, record.Issue, record.WorklogID); err != nil {
+			logger.Error("  - %s worklog %s: %v", record.Issue, record.WorklogID, err)
+			failed++
+			exitCode = ExitCodeFor(err)
+			continue
+		}
+
+		logger.Info("  - Deleted %s worklog %s (%s)", record.Issue, record.WorklogID, FormatSeconds(record.Seconds))
+		RecordDeleted(logger, record)
+	}
+
+	if failed > 0 {
+		logger.Error("Failed to delete %d of %d worklog(s).", failed, len(active))
+		if failed < len(active) {
+			return ExitPartial
+		}
+		return exitCode
+	}
+	logger.Info("Undid submission %s (%d worklog(s)).", target.ID, len(active))
+	return 0
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLedgerSubmissions(t *testing.T) {
+	t.Setenv("WORKLOG_STATE_DIR", t.TempDir())
+
+	now := time.Date(2025, 9, 8, 17, 0, 0, 0, time.UTC)
+	header := func(submission, input string) LedgerRecord {
+		return LedgerRecord{Timestamp: now, Submission: submission, Action: LedgerSubmit, Profile: "jane@example.atlassian.net", Input: input}
+	}
+	post := func(submission, issue, id string) LedgerRecord {
+		return LedgerRecord{Timestamp: now, Submission: submission, Action: LedgerPost, Profile: "jane@example.atlassian.net",
+			Issue: issue, WorklogID: id, Seconds: 3600, Started: "2025-09-08T09:00:00.000+0100"}
+	}
+	deletion := post("a", "PROJ-124", "11")
+	deletion.Action = LedgerDelete
+
+	// Ledgers written before submission headers kept the input on every posted worklog
+	legacy := post("c", "OPS-7", "13")
+	legacy.Input = "OPS-7=1h"
+
+	if err := AppendLedger(header("a", "meetings=1h; PROJ-124=1h"), post("a", "PROJ-123", "10"), post("a", "PROJ-124", "11")); err != nil {
+		t.Fatalf("AppendLedger: %v", err)
+	}
+	if err := AppendLedger(header("b", "OPS-7=1h"), post("b", "OPS-7", "12"), deletion, legacy); err != nil {
+		t.Fatalf("AppendLedger: %v", err)
+	}
+
+	records, err := ReadLedger()
+	if err != nil || len(records) != 7 {
+		t.Fatalf("ReadLedger = %d records, %v", len(records), err)
+	}
+	submissions := LedgerSubmissions(records)
+	if len(submissions) != 3 || submissions[0].ID != "a" || submissions[1].ID != "b" || submissions[2].ID != "c" {
+		t.Fatalf("unexpected submissions %+v", submissions)
+	}
+
+	first := submissions[0]
+	if first.Input != "meetings=1h; PROJ-124=1h" || len(first.Posted) != 2 {
+		t.Errorf("unexpected submission %+v", first)
+	}
+	active := []string{}
+	for _, record := range first.Active() {
+		active = append(active, record.WorklogID)
+	}
+	if !reflect.DeepEqual(active, []string{"10"}) {
+		t.Errorf("Active = %v, want only the worklog that wasn't deleted", active)
+	}
+	if submissions[2].Input != "OPS-7=1h" || len(submissions[2].Posted) != 1 {
+		t.Errorf("unexpected legacy submission %+v", submissions[2])
+	}
+}
+
+func TestRecordSubmission(t *testing.T) {
+	t.Setenv("WORKLOG_STATE_DIR", t.TempDir())
+	settings := &Settings{JiraBaseURL: "https://example.atlassian.net", JiraEmailOrUser: "jane@example.com"}
+	logger := NewLogger("error")
+
+	input := strings.Repeat("PROJ-123=1h\n", 100)
+	RecordSubmission(settings, logger, "a", input)
+	RecordPosted(settings, logger, "a", WorklogResult{Issue: "PROJ-123", ID: "10", Seconds: 3600})
+	RecordPosted(settings, logger, "a", WorklogResult{Issue: "PROJ-123", ID: "11", Seconds: 3600})
+
+	// The input is stored once, on the header
+	path, _ := StatePath(ledgerFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := strings.Count(string(data), "PROJ-123=1h"); count != 100 {
+		t.Errorf("the input was stored %d times over, want once", count/100)
+	}
+
+	records, err := ReadLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	submissions := LedgerSubmissions(records)
+	if len(submissions) != 1 || submissions[0].Input != input || len(submissions[0].Posted) != 2 {
+		t.Errorf("unexpected submissions %+v", submissions)
+	}
+}
+
+func TestRunUndo(t *testing.T) {
+	var mu sync.Mutex
+	deleted := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := filepath.Join(t.TempDir(), "worklog_config.yaml")
+	if err := os.WriteFile(config, []byte("log_level: error\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WORKLOG_CONFIG", config)
+	t.Setenv("WORKLOG_STATE_DIR", t.TempDir())
+	t.Setenv("JIRA_BASE_URL", server.URL)
+	t.Setenv("JIRA_EMAIL", "jane@example.com")
+	t.Setenv("JIRA_API_TOKEN", "secret")
+	t.Setenv("JIRA_API_VERSION", "3")
+	settings := &Settings{JiraBaseURL: server.URL, JiraEmailOrUser: "jane@example.com"}
+
+	record := func(submission, profile, issue, id string) LedgerRecord {
+		return LedgerRecord{Timestamp: time.Now(), Submission: submission, Action: LedgerPost, Profile: profile,
+			Issue: issue, WorklogID: id, Seconds: 1800}
+	}
+	if err := AppendLedger(
+		record("first", settings.Profile(), "PROJ-123", "10"),
+		record("other", "someone@else.atlassian.net", "PROJ-123", "20"),
+		record("last", settings.Profile(), "PROJ-124", "30"),
+		record("last", settings.Profile(), "OPS-7", "31"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// Submissions of other profiles are refused
+	if code := runUndo([]string{"other", "--yes"}); code != 1 {
+		t.Errorf("undoing another profile's submission returned %d, want 1", code)
+	}
+
+	// The latest submission is undone first, then the one before it
+	if code := runUndo([]string{"--yes"}); code != 0 {
+		t.Fatalf("runUndo returned %d", code)
+	}
+	if code := runUndo([]string{"--yes"}); code != 0 {
+		t.Fatalf("runUndo returned %d", code)
+	}
+	if code := runUndo([]string{"--yes"}); code != 0 {
+		t.Fatalf("runUndo with nothing left returned %d", code)
+	}
+	want := []string{
+		"/rest/api/3/issue/PROJ-124/worklog/30",
+		"/rest/api/3/issue/OPS-7/worklog/31",
+		"/rest/api/3/issue/PROJ-123/worklog/10",
+	}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted\n got %q\nwant %q", deleted, want)
+	}
+
+	records, err := ReadLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, submission := range LedgerSubmissions(records) {
+		if submission.ID != "other" && len(submission.Active()) != 0 {
+			t.Errorf("submission %s still has worklogs %+v", submission.ID, submission.Active())
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ListResult lists what time can be logged to: the aliases and the issues assigned to the user
+type ListResult struct {
+	Aliases map[string]string `json:"aliases" yaml:"aliases"`
+	Issues  []ListIssue       `json:"issues" yaml:"issues"`
+}
+
+// ListIssue is an issue assigned to the user
+type ListIssue struct {
+	Key         string `json:"key" yaml:"key"`
+	Summary     string `json:"summary" yaml:"summary"`
+	Status      string `json:"status,omitempty" yaml:"status,omitempty"`
+	Epic        string `json:"epic,omitempty" yaml:"epic,omitempty"`
+	EpicSummary string `json:"epic_summary,omitempty" yaml:"epic_summary,omitempty"`
+}
+
+// runList lists the category aliases and the open issues assigned to the user, with their epics
+func runList(args []string) int {
+	outputFormat := OutputTable
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--output" && i+1 < len(args):
+			format, err := ParseOutputFormat(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+				return ExitConfig
+			}
+			outputFormat = format
+			i++
+		default:
+			fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger list [--output json|yaml|table]")
+			return ExitConfig
+		}
+	}
+
+	settings, logger := setup()
+	issues, err := GetAssignedIssues(settings, logger)
+	if err != nil {
+		logger.Error("Failed to load issues: %v", err)
+		return ExitCodeFor(err)
+	}
+
+	result := ListResult{Aliases: settings.CategoryAliases, Issues: []ListIssue{}}
+	if result.Aliases == nil {
+		result.Aliases = map[string]string{}
+	}
+	for _, issue := range issues {
+		listed := ListIssue{Key: issue.Key, Summary: issue.Fields.Summary}
+		if issue.Fields.Status != nil {
+			listed.Status = issue.Fields.Status.Name
+		}
+		if epic, ok := IssueEpic(issue); ok && epic.Key != issue.Key {
+			listed.Epic, listed.EpicSummary = epic.Key, epic.Summary
+		}
+		result.Issues = append(result.Issues, listed)
+	}
+	sort.SliceStable(result.Issues, func(i, j int) bool { return result.Issues[i].Key < result.Issues[j].Key })
+
+	if outputFormat != OutputTable {
+		if err := WriteOutput(outputFormat, result); err != nil {
+			logger.Error("Failed to write the list: %v", err)
+			return ExitFailure
+		}
+		return ExitOK
+	}
+
+	if len(result.Aliases) > 0 {
+		fmt.Println("Aliases:")
+		names := make([]string, 0, len(result.Aliases))
+		for name := range result.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %-12s -> %s\n", name, result.Aliases[name])
+		}
+		fmt.Println()
+	}
+	if len(result.Issues) == 0 {
+		fmt.Println("No open issues are assigned to you.")
+		return ExitOK
+	}
+	fmt.Println("Assigned issues:")
+	for _, issue := range result.Issues {
+		epic := ""
+		if issue.Epic != "" {
+			epic = fmt.Sprintf("  (epic %s)", issue.Epic)
+		}
+		fmt.Printf("  %-12s %-14s %s%s\n", issue.Key, issue.Status, issue.Summary, epic)
+	}
+	return ExitOK
+}
@@ -1,64 +1,290 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
 	"strings"
+	"sync"
 )
 
-// Log levels
-const (
-	LogDebug = iota
-	LogInfo
-	LogWarn
-	LogError
-)
-
-var logLevelMap = map[string]int{
-	"debug": LogDebug,
-	"info":  LogInfo,
-	"warn":  LogWarn,
-	"error": LogError,
+var logLevelMap = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
 }
 
-// Logger provides logging functionality with configurable level
+// logOutput receives console log messages. It is stderr so that stdout only holds prompts and reports.
+var logOutput io.Writer = os.Stderr
+
+// logSinks holds the logging configuration shared by every logger, set up by ConfigureLogging
+var logSinks = struct {
+	format string    // Console format: text or json
+	file   io.Writer // Optional log file, always JSON
+	redact *Redactor
+}{format: "text", redact: &Redactor{}}
+
+// Logger provides leveled logging on top of log/slog, with printf-style helpers
 type Logger struct {
-	level int
+	slog *slog.Logger
 }
 
-// NewLogger creates a new logger with the specified level
+// NewLogger creates a new logger with the specified level, writing to the configured sinks
 func NewLogger(levelStr string) *Logger {
 	level, ok := logLevelMap[strings.ToLower(levelStr)]
 	if !ok {
-		level = LogInfo // Default to info if invalid
-		fmt.Printf("[warn] Invalid log level '%s', defaulting to 'info'\n", levelStr)
+		level = slog.LevelInfo // Default to info if invalid
+		fmt.Fprintf(logOutput, "[warn] Invalid log level '%s', defaulting to 'info'\n", levelStr)
 	}
-	return &Logger{level: level}
+
+	options := &slog.HandlerOptions{Level: level}
+	var console slog.Handler = &consoleHandler{out: logOutput, level: level, mu: &sync.Mutex{}}
+	if logSinks.format == "json" {
+		console = slog.NewJSONHandler(logOutput, options)
+	}
+	handlers := []slog.Handler{console}
+	if logSinks.file != nil {
+		handlers = append(handlers, slog.NewJSONHandler(logSinks.file, options))
+	}
+
+	return &Logger{slog: slog.New(&redactingHandler{next: fanoutHandler(handlers), redactor: logSinks.redact})}
+}
+
+// Slog returns the underlying structured logger
+func (l *Logger) Slog() *slog.Logger {
+	return l.slog
 }
 
 // Debug logs a debug message
 func (l *Logger) Debug(format string, args ...interface{}) {
-	if l.level <= LogDebug {
-		fmt.Printf("[debug] "+format+"\n", args...)
-	}
+	l.slog.Debug(fmt.Sprintf(format, args...))
 }
 
 // Info logs an info message
 func (l *Logger) Info(format string, args ...interface{}) {
-	if l.level <= LogInfo {
-		fmt.Printf("[info] "+format+"\n", args...)
-	}
+	l.slog.Info(fmt.Sprintf(format, args...))
 }
 
 // Warn logs a warning message
 func (l *Logger) Warn(format string, args ...interface{}) {
-	if l.level <= LogWarn {
-		fmt.Printf("[warn] "+format+"\n", args...)
-	}
+	l.slog.Warn(fmt.Sprintf(format, args...))
 }
 
 // Error logs an error message
 func (l *Logger) Error(format string, args ...interface{}) {
-	if l.level <= LogError {
-		fmt.Printf("[error] "+format+"\n", args...)
+	l.slog.Error(fmt.Sprintf(format, args...))
+}
+
+// ConfigureLogging sets up the console format, the log file and the secrets to redact from the
+// settings. Loggers created afterwards use them.
+func ConfigureLogging(settings *Settings) error {
+	logSinks.format = settings.Logging.Format
+	logSinks.redact = NewRedactor(settings)
+
+	if settings.Logging.File != "" {
+		maxSize, err := ParseByteSize(settings.Logging.FileMaxSize)
+		if err != nil {
+			return fmt.Errorf("invalid logging.file_max_size: %v", err)
+		}
+		file, err := OpenRotatingFile(settings.Logging.File, maxSize, settings.Logging.FileMaxBackups)
+		if err != nil {
+			return err
+		}
+		logSinks.file = file
+	}
+	return nil
+}
+
+// consoleHandler writes records the way the tool always printed them, e.g. "[info] Posted 3 worklogs",
+// followed by their attributes as key=value pairs
+type consoleHandler struct {
+	out   io.Writer
+	level slog.Level
+	attrs []slog.Attr
+	group string
+	mu    *sync.Mutex
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, record slog.Record) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", strings.ToLower(record.Level.String()), record.Message)
+	writeAttr := func(attr slog.Attr) bool {
+		if h.group != "" {
+			attr.Key = h.group + "." + attr.Key
+		}
+		fmt.Fprintf(&b, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		writeAttr(attr)
+	}
+	record.Attrs(writeAttr)
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, b.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	if clone.group != "" {
+		name = clone.group + "." + name
+	}
+	clone.group = name
+	return &clone
+}
+
+// fanoutHandler sends records to several handlers
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var firstErr error
+	for _, h := range f {
+		if !h.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, record.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// redactingHandler removes secrets from messages and attributes before they reach any sink
+type redactingHandler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactor.RedactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactor.RedactAttr(attr)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+// redactedText replaces secrets in log output
+const redactedText = "[REDACTED]"
+
+var (
+	authHeaderPattern = regexp.MustCompile(`\b(Basic|Bearer)\s+[A-Za-z0-9+/=._~-]{8,}`)
+	emailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	secretKeyPattern  = regexp.MustCompile(`(?i)(token|password|secret|authorization|cookie)`)
+)
+
+// Redactor removes secrets from log messages: the API token and its Basic auth encoding,
+// any Basic or Bearer credentials, attributes named like secrets and, optionally, email addresses
+type Redactor struct {
+	secrets      []string
+	redactEmails bool
+}
+
+// NewRedactor returns a redactor for the secrets of the settings
+func NewRedactor(settings *Settings) *Redactor {
+	r := &Redactor{redactEmails: settings.Logging.RedactEmails}
+	if token := settings.JiraAPIToken; len(token) >= 4 {
+		credentials := settings.JiraEmailOrUser + ":" + token
+		r.secrets = append(r.secrets, token, base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	return r
+}
+
+// Redact removes secrets from a string
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedText)
+	}
+	s = authHeaderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		// Credentials have digits or base64 symbols, plain words such as "Basic authentication" don't
+		scheme, credentials, _ := strings.Cut(match, " ")
+		if !strings.ContainsAny(strings.TrimSpace(credentials), "0123456789+/=") {
+			return match
+		}
+		return scheme + " " + redactedText
+	})
+	if r.redactEmails {
+		s = emailPattern.ReplaceAllString(s, "[EMAIL]")
+	}
+	return s
+}
+
+// RedactAttr removes secrets from an attribute, hiding the whole value when the key names a secret
+func (r *Redactor) RedactAttr(attr slog.Attr) slog.Attr {
+	switch {
+	case secretKeyPattern.MatchString(attr.Key):
+		return slog.String(attr.Key, redactedText)
+	case attr.Value.Kind() == slog.KindGroup:
+		group := attr.Value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = r.RedactAttr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case attr.Value.Kind() == slog.KindString, attr.Value.Kind() == slog.KindAny:
+		return slog.String(attr.Key, r.Redact(attr.Value.Resolve().String()))
 	}
+	return attr
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"10MB", 10 << 20, false},
+		{"512 kb", 512 << 10, false},
+		{"1GB", 1 << 30, false},
+		{"100B", 100, false},
+		{"1048576", 1 << 20, false},
+		{"0", 0, true},
+		{"lots", 0, true},
+		{"1.5MB", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxBackups int
+		want       map[string]string // Contents of the log files after writing lines a to e
+	}{
+		{
+			name:       "keeps backups",
+			maxBackups: 2,
+			want:       map[string]string{"app.log": "e", "app.log.1": "cd", "app.log.2": "ab"},
+		},
+		{
+			name:       "without backups",
+			maxBackups: 0,
+			want:       map[string]string{"app.log": "e"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "logs", "app.log")
+
+			// Each line is 40 bytes, so two fit in the 100 byte limit
+			writer, err := OpenRotatingFile(path, 100, tt.maxBackups)
+			if err != nil {
+				t.Fatalf("OpenRotatingFile: %v", err)
+			}
+			for _, line := range []string{"a", "b", "c", "d", "e"} {
+				if _, err := writer.Write([]byte(strings.Repeat(line, 39) + "\n")); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			writer.(*rotatingFile).file.Close()
+
+			entries, _ := os.ReadDir(filepath.Join(dir, "logs"))
+			if len(entries) != len(tt.want) {
+				t.Errorf("expected %d log files, got %d", len(tt.want), len(entries))
+			}
+			for name, lines := range tt.want {
+				data, err := os.ReadFile(filepath.Join(dir, "logs", name))
+				if err != nil {
+					t.Fatalf("reading %s: %v", name, err)
+				}
+				got := ""
+				for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+					got += line[:1]
+				}
+				if got != lines {
+					t.Errorf("%s holds lines %q, want %q", name, got, lines)
+				}
+			}
+		})
+	}
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 90)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The size of the existing file counts towards the limit
+	writer, err := OpenRotatingFile(path, 100, 1)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile: %v", err)
+	}
+	writer.Write([]byte("0123456789ab"))
+	writer.(*rotatingFile).file.Close()
+
+	if data, _ := os.ReadFile(path + ".1"); len(data) != 90 {
+		t.Errorf("expected the existing file to be rotated, got %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "0123456789ab" {
+		t.Errorf("expected a new log file, got %q", data)
+	}
+}
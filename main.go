@@ -6,6 +6,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 )
 
 func showHelp() {
@@ -13,6 +14,7 @@ func showHelp() {
 Jira Worklogger v%s - Command-line tool for posting worklogs to Jira Cloud/Server
 
 Usage: jira-worklogger [options]
+       jira-worklogger <command> [arguments]
 
 Options:
   --help, -h             Show this help message and exit
@@ -20,6 +22,83 @@ Options:
   --date DATE            Set the worklog date (format: YYYY-MM-DD, default: today)
   --entries ENTRIES      Specify time entries directly (e.g., "meetings=1h;support=30m")
                         Skips the interactive prompt when provided
+                        Use "-" to read entries from stdin
+                        Prefix entries with a date header to cover several days
+                        (e.g., "mon: meetings=1h; tue: PROJ-123=6h")
+  --entries-file PATH    Read time entries from a file (same format as --entries)
+  --input-format FORMAT  Format of the entries: text (default), json or yaml
+                        Structured input is a list of records, see README
+  --week WEEK            Log a whole week as a grid (format: YYYY-Www, e.g. 2025-W37)
+                        Opens a pre-filled grid in $VISUAL/$EDITOR when set,
+                        otherwise rows are typed at the prompt
+  --copy-from PERIOD     Pre-fill the entries with the worklogs of yesterday, a date
+                        or last-week (onto the same weekdays of the week of --date),
+                        opened in $VISUAL/$EDITOR or the review for adjustment
+  --preset NAME          Log the entries of a preset of the config, plus any given
+                        with --entries (a * duration fills the rest of the day)
+  --yes                  Post interactive entries without the review prompt
+  --partial              Post the valid entries even when some issues don't exist,
+                        aren't visible or are in a disallowed status
+  --atomic               All or nothing: if any worklog fails to post, delete the
+                        worklogs already posted in this run
+  --no-recurring         Don't add the recurring entries of the config
+  --retry-last           Same as the retry command
+  --output FORMAT        Report format: table (default, human readable), json or
+                        yaml. json and yaml print one document with the posted and
+                        failed worklogs to stdout and send logging to stderr
+  --edit                 Open the time entries in $VISUAL/$EDITOR with a template
+                        listing aliases, suggested epics and existing worklogs
+                        (also used when nothing is typed at the prompt)
+  --trace-http FILE      Record the Jira requests and responses to a HAR file, with
+                        credentials redacted (accepted by every command)
+
+Timer Commands:
+  start ALIAS|ISSUE      Start a timer for an alias or issue
+  stop                   Stop the running timer
+  switch ALIAS|ISSUE     Stop the running timer and start another
+  status                 Show the running timer and unposted segments
+  discard [--all]        Discard the running timer (--all: and unposted segments)
+  post                   Post finished segments with their real start times,
+                        grouped per day and issue and rounded by timer.rounding
+
+History Commands:
+  history [SUBMISSION]   List recent submissions (--limit N), or show one
+                        (--output json|yaml for machine-readable output)
+  undo [SUBMISSION]      Delete the worklogs of the last (or given) submission
+                        from Jira, asking for confirmation unless --yes is given
+  retry [--all]          Re-post the entries that failed in the previous run
+                        (--all: including auth and validation failures)
+
+Setup Commands:
+  list                   List your aliases and the open issues assigned to you,
+                        with their epics (--output json|yaml|table)
+  doctor                 Check the config, the state directory and the connection
+                        to Jira, exiting with the code of the first failed check
+                        (--output json|yaml|table)
+
+Leave Commands:
+  leave RANGE            Log the target of each working day of RANGE (e.g.
+                        2025-12-22..2025-12-31) to the issue of a leave type
+                        --type TYPE (from the leave section of the config),
+                        --skip-logged to skip days with time logged instead of
+                        refusing, --yes to skip the confirmation
+
+Report Commands:
+  report [RANGE]         Sum up your worklogs for today, yesterday, this-week
+                        (default), last-week, this-month, last-month, 2025-W37,
+                        a date or FROM..TO, against the daily target
+                        --by day|issue|epic|project|alias (default day)
+                        --output table|csv|markdown|json|yaml
+  check [RANGE]          List working days below the daily target (default this
+                        week up to yesterday, or last week on a Monday) and
+                        exit with code 7 if any,
+                        skipping days off in the working calendar
+                        --include-today, --quiet, --output json|yaml|table
+
+Development Commands:
+  mock-server            Serve a fake in-memory Jira (API v2 and v3) for demos and
+                        tests: --addr HOST:PORT (default 127.0.0.1:8080),
+                        --fixture FILE, --fail METHOD:PATH:STATUS[:COUNT]
 
 Configuration:
   The tool looks for configuration in the following locations:
@@ -36,9 +115,27 @@ Environment Variables:
   TIMEZONE        - Your timezone (e.g. Europe/London)
   JIRA_API_VERSION - API version (2 for Server, 3 for Cloud)
   LOG_LEVEL       - Logging verbosity (debug, info, warn, error)
+  LOG_FORMAT      - Log format on stderr (text, json)
+  LOG_FILE        - Also write JSON logs to this file, rotated at logging.file_max_size
+  WORKLOG_STATE_DIR - Directory for local state (timer, ledger of posted worklogs)
+
+Pipelines:
+  When stdin is not a terminal, time entries are read from stdin and the
+  interactive prompt is skipped, e.g.:
+    printf 'meetings=1h\nsupport=30m\n' | jira-worklogger --date 2025-09-08
+
+Exit Codes:
+  0  Success
+  1  Nothing could be posted, or another error
+  2  Configuration or command line error
+  3  Authentication or permission error
+  4  Invalid time entries, or issues that can't be logged to
+  5  Partial failure: some worklogs were posted, others failed
+  6  Network error: Jira could not be reached
+  7  check found working days below the daily target
 `, Version)
-	
-	fmt.Println(`
+
+	fmt.Print(`
 Category Aliases:
   You can define category aliases in your config file under defaults.category_aliases
   These allow you to use shorthand names instead of typing full Jira issue keys.
@@ -52,6 +149,51 @@ Category Aliases:
         
   Usage:
     Time entries: meetings=1h; support=30m; docs=2h
+    With options: meetings=1h @09:30 [role:Developers] "Sprint planning"
+
+Working Calendar:
+  The calendar section of the config sets the working days, the target per
+  weekday, public holidays (bundled by country or from .ics files) and PTO.
+  Reports and checks skip days off, and posting to one logs a warning.
+
+    calendar:
+      targets: {fri: "4h"}
+      country: "GB"
+      pto: [2025-08-11..2025-08-15]
+
+Recurring Entries:
+  Rules in the recurring section of the config add entries such as standups to
+  every working day you log time on, unless --no-recurring is given. The review
+  marks them as auto-added.
+
+    recurring:
+      - {name: standup, alias: meetings, duration: 15m, start: "09:30"}
+      - {name: retro, alias: meetings, duration: 1h, days: [wed], interval: 2, start_date: 2025-09-03}
+
+Presets:
+  The presets section of the config names lists of alias=duration lines with an
+  optional # comment. A duration of * fills what is left of the day's target.
+
+    presets:
+      support-day: ["support=*  # Support rota", "meetings=1h  # Triage"]
+
+    jira-worklogger --preset support-day --entries "PROJ-123=2h"
+
+Multiple Days:
+  Start a line (or entry) with a date header to log against another day.
+  Headers are YYYY-MM-DD dates or weekday names (mon, tue, ...), which refer
+  to the most recent such day on or before --date. Every day is validated
+  before anything is posted.
+
+    2025-09-08: meetings=1h; PROJ-123=6h
+    tue: support=2h; docs=5h
+
+Week Grid:
+  With --week, each row is an alias or issue key followed by one duration per
+  day, Monday first. Column totals, recurring entries included, are shown
+  before anything is posted.
+
+    jira-worklogger --week 2025-W37 --entries "meetings 1h 1h 0 2h 1h; PROJ-123 6h 6h 7h 5h 6h"
 `)
 }
 
@@ -67,13 +209,13 @@ func promptUser(settings *Settings, epics map[string]Epic) (map[string]string, e
 	// Show epics if available
 	if len(epics) > 0 {
 		fmt.Println("\nSuggested Epics (You Are Possibly Working On):")
-		
+
 		// Convert map to a slice for sorting
 		epicsList := make([]Epic, 0, len(epics))
 		for _, epic := range epics {
 			epicsList = append(epicsList, epic)
 		}
-		
+
 		// Display epics
 		for i, epic := range epicsList {
 			fmt.Printf("  %d. %s: %s\n", i+1, epic.Key, epic.Summary)
@@ -84,14 +226,14 @@ func promptUser(settings *Settings, epics map[string]Epic) (map[string]string, e
 	// Show category aliases if available
 	if len(settings.CategoryAliases) > 0 {
 		fmt.Println("\nAvailable Category Aliases:")
-		
+
 		// Get keys for sorting
 		keys := make([]string, 0, len(settings.CategoryAliases))
 		for k := range settings.CategoryAliases {
 			keys = append(keys, k)
 		}
 		sort.Strings(keys)
-		
+
 		// Display aliases
 		for _, k := range keys {
 			fmt.Printf("  %-10s -> %s\n", k, settings.CategoryAliases[k])
@@ -114,11 +256,87 @@ func promptUser(settings *Settings, epics map[string]Epic) (map[string]string, e
 	return results, nil
 }
 
+// promptWeekGrid collects week grid rows, either in the user's editor or typed at the prompt
+func promptWeekGrid(settings *Settings, weekStr string, monday time.Time, epics map[string]Epic) (string, error) {
+	template := BuildWeekGridTemplate(weekStr, monday, settings.CategoryAliases, epics)
+
+	if EditorCommand() != "" {
+		return EditText(template)
+	}
+
+	fmt.Println("=== Jira Worklogger ===")
+	fmt.Print(template)
+	fmt.Println("\nEnter one row per alias or issue (e.g., meetings 1h 1h 0 2h 1h), finish with an empty line:")
+
+	reader := bufio.NewReader(os.Stdin)
+	rows := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" || err != nil {
+			if line != "" {
+				rows = append(rows, line)
+			}
+			break
+		}
+		rows = append(rows, line)
+	}
+
+	return strings.Join(rows, "\n"), nil
+}
+
+// editEntries opens a pre-populated entries template for the date, followed by the initial
+// entries, in the user's editor
+func editEntries(settings *Settings, logger *Logger, dateStr string, epics map[string]Epic, initial string) (string, error) {
+	existing, err := GetMyWorklogs(settings, logger, dateStr, dateStr)
+	if err != nil {
+		logger.Warn("Failed to load existing worklogs for %s: %v", dateStr, err)
+	}
+
+	template := BuildEntriesTemplate(dateStr, settings.CategoryAliases, epics, existing) + initial
+
+	// Validate quietly, the final parse reports aliases as usual
+	quietLogger := NewLogger("error")
+	return EditEntries(template, func(text string) error {
+		_, err := ParseTimeEntries(text, dateStr, settings.CategoryAliases, quietLogger)
+		return err
+	})
+}
+
 func main() {
 	// Initialize command line options
 	cmdLineOptions := map[string]string{
-		"date":    "",
-		"entries": "",
+		"date":         "",
+		"entries":      "",
+		"entries-file": "",
+		"input-format": "",
+		"week":         "",
+		"output":       "",
+		"copy-from":    "",
+		"preset":       "",
+	}
+	cmdLineFlags := map[string]bool{
+		"edit":         false,
+		"retry-last":   false,
+		"yes":          false,
+		"partial":      false,
+		"atomic":       false,
+		"no-recurring": false,
+	}
+
+	// Record the Jira traffic of any command
+	args, err := SetupHTTPTrace(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		os.Exit(ExitConfig)
+	}
+	os.Args = append(os.Args[:1], args...)
+
+	// Dispatch subcommands
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
 	}
 
 	// Check for help flag
@@ -126,7 +344,7 @@ func main() {
 		showHelp()
 		return
 	}
-	
+
 	// Check for version flag
 	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
 		fmt.Printf("Jira Worklogger v%s\n", Version)
@@ -140,42 +358,69 @@ func main() {
 		arg := os.Args[i]
 		if strings.HasPrefix(arg, "--") {
 			key := strings.TrimPrefix(arg, "--")
-			
+
+			// Boolean flags take no value
+			if _, isFlag := cmdLineFlags[key]; isFlag {
+				cmdLineFlags[key] = true
+				continue
+			}
+
 			// Skip to next argument if this is the last one or next is another flag
 			if i+1 >= len(os.Args) || strings.HasPrefix(os.Args[i+1], "--") {
-				fmt.Printf("[error] No value provided for flag %s\n", arg)
-				os.Exit(1)
+				fmt.Fprintf(os.Stderr, "[error] No value provided for flag %s\n", arg)
+				os.Exit(ExitConfig)
 			}
-			
+
 			// Get the value
 			value := os.Args[i+1]
 			i++ // Skip the next argument as it's a value
-			
+
 			// Store the value
 			if _, exists := cmdLineOptions[key]; exists {
 				cmdLineOptions[key] = value
 			} else {
-				fmt.Printf("[warn] Unknown flag: %s\n", arg)
+				fmt.Fprintf(os.Stderr, "[warn] Unknown flag: %s\n", arg)
 			}
+		} else {
+			// Arguments that are neither flags nor their values would otherwise be ignored
+			fmt.Fprintf(os.Stderr, "[error] Unknown command or argument: %s (see --help)\n", arg)
+			os.Exit(ExitConfig)
 		}
 	}
 
-	// Load settings
-	settings, err := LoadSettings()
+	// Machine-readable output keeps stdout for the final report
+	outputFormat, err := ParseOutputFormat(cmdLineOptions["output"])
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
-		os.Exit(1)
+		os.Exit(ExitConfig)
 	}
 
-	// Initialize logger
-	logger := NewLogger(settings.LogLevel)
-	logger.Info("Starting jira-worklogger with log level: %s", settings.LogLevel)
+	// Re-post the failures of the previous run instead of new entries
+	if cmdLineFlags["retry-last"] {
+		os.Exit(runRetry(nil))
+	}
+
+	// Exit through the report, so --output json and yaml always print one, even when the
+	// settings can't be loaded
+	report := NewRunReport()
+	var logger *Logger
+	exit := func(code int) {
+		if outputFormat != OutputTable {
+			report.Finish(code)
+			if err := WriteOutput(outputFormat, report); err != nil {
+				logSetupError(logger, fmt.Errorf("failed to write the report: %v", err))
+			}
+		}
+		os.Exit(code)
+	}
 
-	// Check for placeholder API token
-	if settings.JiraAPIToken == "YOUR_API_TOKEN" {
-		logger.Error("Please update your API token in worklog_config.yaml - it's currently set to the placeholder value 'YOUR_API_TOKEN'")
-		os.Exit(1)
+	settings, logger, err := loadSetup()
+	if err != nil {
+		logSetupError(logger, err)
+		report.Error = err.Error()
+		exit(ExitConfig)
 	}
+	logger.Info("Starting jira-worklogger with log level: %s", settings.LogLevel)
 
 	// Fetch assigned issues and extract epics
 	epics := make(map[string]Epic)
@@ -186,61 +431,313 @@ func main() {
 		epics = GetEpicsFromIssues(issues)
 	}
 
-	// Prepare user input (either from command-line or interactive prompts)
-	var userInput map[string]string
-	
-	// Check if we have command-line parameters for non-interactive mode
-	if cmdLineOptions["entries"] != "" {
-		// Non-interactive mode
-		userInput = map[string]string{
-			"date":    cmdLineOptions["date"],
-			"entries": cmdLineOptions["entries"],
+	// Read entries from the command line, a file or a pipe
+	copyFrom := cmdLineOptions["copy-from"]
+	entriesInput, haveEntries, err := ReadEntriesInput(cmdLineOptions["entries"], cmdLineOptions["entries-file"], !cmdLineFlags["edit"] && copyFrom == "")
+	if err != nil {
+		logger.Error("%v", err)
+		exit(ExitConfig)
+	}
+	if copyFrom != "" && (haveEntries || cmdLineOptions["week"] != "") {
+		logger.Error("--copy-from cannot be used with --entries, --entries-file or --week")
+		exit(ExitConfig)
+	}
+	preset := cmdLineOptions["preset"]
+	if preset != "" && cmdLineOptions["week"] != "" {
+		logger.Error("--preset cannot be used with --week")
+		exit(ExitConfig)
+	}
+	if outputFormat != OutputTable && !haveEntries && (copyFrom == "" || !cmdLineFlags["yes"]) {
+		logger.Error("--output %s requires --entries, --entries-file, piped stdin or --copy-from with --yes", outputFormat)
+		exit(ExitConfig)
+	}
+
+	// Structured input is only read from the command line, a file or a pipe
+	inputFormat := strings.ToLower(cmdLineOptions["input-format"])
+	if inputFormat == "" {
+		inputFormat = "text"
+	}
+	if inputFormat != "text" && (!haveEntries || cmdLineOptions["week"] != "") {
+		logger.Error("--input-format %s requires --entries, --entries-file or piped stdin and cannot be used with --week", inputFormat)
+		exit(ExitConfig)
+	}
+
+	var entries []TimeEntry
+	var rawInput string
+	var baseDate string      // Date of entries without a date header
+	var weekMonday time.Time // Monday of the --week grid
+
+	if cmdLineOptions["week"] != "" {
+		// Week grid mode - one row per alias or issue, one column per day
+		monday, err := ParseISOWeek(cmdLineOptions["week"])
+		if err != nil {
+			logger.Error("Failed to parse week: %v", err)
+			exit(ExitConfig)
+		}
+
+		gridInput := entriesInput
+		if !haveEntries {
+			gridInput, err = promptWeekGrid(settings, cmdLineOptions["week"], monday, epics)
+			if err != nil {
+				logger.Error("Failed to get user input: %v", err)
+				exit(ExitFailure)
+			}
+		}
+
+		rawInput = gridInput
+		baseDate = monday.Format("2006-01-02")
+		entries, err = ParseWeekGrid(gridInput, monday, settings.CategoryAliases, logger)
+		if err != nil {
+			logger.Error("Failed to parse week grid: %v", err)
+			exit(ExitValidation)
 		}
-		logger.Info("Running in non-interactive mode with provided parameters")
+		weekMonday = monday
 	} else {
-		// Interactive mode - prompt user for input
-		userInput, err = promptUser(settings, epics)
+		// Prepare user input (either from command-line or interactive prompts)
+		var userInput map[string]string
+
+		// Check if we have command-line parameters for non-interactive mode
+		if haveEntries {
+			// Non-interactive mode
+			userInput = map[string]string{
+				"date":    cmdLineOptions["date"],
+				"entries": entriesInput,
+			}
+			logger.Info("Running in non-interactive mode with provided parameters")
+		} else if copyFrom != "" {
+			// Copy mode - the worklogs of another day or week are adjusted below
+			userInput = map[string]string{
+				"date":    cmdLineOptions["date"],
+				"entries": "",
+			}
+		} else if preset != "" {
+			// Preset mode - extra entries are only asked for with --edit
+			userInput = map[string]string{
+				"date":    cmdLineOptions["date"],
+				"entries": "",
+			}
+		} else if cmdLineFlags["edit"] {
+			// Editor mode - entries are collected below
+			userInput = map[string]string{
+				"date":    cmdLineOptions["date"],
+				"entries": "",
+			}
+		} else {
+			// Interactive mode - prompt user for input
+			userInput, err = promptUser(settings, epics)
+			if err != nil {
+				logger.Error("Failed to get user input: %v", err)
+				exit(ExitFailure)
+			}
+		}
+
+		// Parse date used for entries without a date header
+		dateStr := userInput["date"]
+		if dateStr == "" {
+			dateStr = DefaultDateStr()
+		}
+
+		// Pre-fill the entries with the worklogs of another day or week
+		openEditor := cmdLineFlags["edit"] || (userInput["entries"] == "" && EditorCommand() != "" && preset == "")
+		if copyFrom != "" {
+			copied, err := CopyEntries(settings, logger, copyFrom, dateStr, cmdLineFlags["no-recurring"])
+			if err != nil {
+				logger.Error("Failed to copy worklogs from %s: %v", copyFrom, err)
+				exit(ExitCodeFor(err))
+			}
+			if len(copied) == 0 {
+				logger.Warn("No worklogs to copy from %s", copyFrom)
+			}
+			userInput["entries"] = FormatEntriesText(GroupEntriesByDate(copied))
+			openEditor = !cmdLineFlags["yes"] && (cmdLineFlags["edit"] || EditorCommand() != "")
+		}
+
+		// Open the editor when requested, or as a fallback when nothing was typed at the prompt
+		if !haveEntries && openEditor {
+			userInput["entries"], err = editEntries(settings, logger, dateStr, epics, userInput["entries"])
+			if err != nil {
+				logger.Error("Failed to edit time entries: %v", err)
+				exit(ExitFailure)
+			}
+		}
+
+		// Parse time entries
+		rawInput = userInput["entries"]
+		baseDate = dateStr
+		if inputFormat != "text" {
+			entries, err = ParseStructuredEntries(userInput["entries"], inputFormat, dateStr, settings.CategoryAliases, logger)
+		} else {
+			entries, err = ParseTimeEntries(userInput["entries"], dateStr, settings.CategoryAliases, logger)
+		}
 		if err != nil {
-			logger.Error("Failed to get user input: %v", err)
-			os.Exit(1)
+			logger.Error("Failed to parse time entries: %v", err)
+			exit(ExitValidation)
 		}
-	}
 
-	// Parse date and create ISO timestamp
-	dateStr := userInput["date"]
-	if dateStr == "" {
-		dateStr = DefaultDateStr()
+		// Log the entries of the preset before the extra ones
+		if preset != "" {
+			presetEntries, err := PresetEntries(settings, logger, preset, dateStr)
+			if err != nil {
+				logger.Error("%v", err)
+				exit(ExitConfig)
+			}
+			entries = append(presetEntries, entries...)
+			rawInput = fmt.Sprintf("# preset %s\n%s", preset, rawInput)
+		}
 	}
-	
-	startedISO, err := ISOStartForDate(dateStr, 17, 0, settings.Timezone, logger)
-	if err != nil {
-		logger.Error("Failed to parse date: %v", err)
-		os.Exit(1)
+
+	// Merge the recurring entries of the config into each day
+	if !cmdLineFlags["no-recurring"] {
+		entries = ApplyRecurring(settings, logger, entries)
 	}
 
-	// Parse time entries
-	entries, err := ParseTimeEntries(userInput["entries"], settings.CategoryAliases, logger)
-	if err != nil {
-		logger.Error("Failed to parse time entries: %v", err)
-		os.Exit(1)
+	// Fill the rest of each day's target with the remainder (*) entries of the preset
+	entries = ResolveRemainders(settings, logger, entries)
+
+	// Show the column totals of the week grid, recurring entries included
+	if !weekMonday.IsZero() && len(entries) > 0 {
+		logger.Info("Week %s totals:", cmdLineOptions["week"])
+		for _, line := range WeekColumnTotals(weekMonday, entries) {
+			logger.Info("  %s", line)
+		}
 	}
 
+	report.Date = baseDate
 	if len(entries) == 0 {
 		logger.Info("No time entries to post. Exiting.")
-		return
+		exit(ExitOK)
+	}
+
+	// Resolve the start timestamp of every entry before posting anything
+	days := GroupEntriesByDate(entries)
+	if err := ResolveStartedISO(days, settings.Timezone, logger); err != nil {
+		logger.Error("%v", err)
+		exit(ExitValidation)
 	}
+	WarnNonWorkingDays(settings, logger, days)
 
-	// Post worklogs
+	// Let the user review entries typed interactively before they are posted
+	if !haveEntries && !cmdLineFlags["yes"] {
+		var confirmed bool
+		days, confirmed = reviewEntries(settings, logger, entries, func(text string) ([]TimeEntry, error) {
+			return ParseEditedEntries(settings, logger, text, baseDate, !cmdLineFlags["no-recurring"])
+		})
+		if !confirmed {
+			logger.Info("Aborted, nothing was posted.")
+			exit(ExitFailure)
+		}
+		entries = []TimeEntry{}
+		for _, day := range days {
+			entries = append(entries, day.Entries...)
+		}
+	}
+	report.AddEntries(entries)
+
+	// Check every target issue before posting anything, so a typo can't leave a half-logged day
+	issueKeys := []string{}
+	for _, day := range days {
+		for _, entry := range day.Entries {
+			issueKeys = append(issueKeys, entry.Issue)
+		}
+	}
+	problems, err := ValidateIssues(settings, logger, issueKeys)
+	if err != nil {
+		logger.Error("Failed to validate issues: %v", err)
+		exit(ExitCodeFor(err))
+	}
+	if len(problems) > 0 {
+		candidates := SuggestionCandidates(settings, issues, epics)
+		problemKeys := make([]string, 0, len(problems))
+		for key := range problems {
+			problemKeys = append(problemKeys, key)
+		}
+		sort.Strings(problemKeys)
+
+		logger.Error("Some issues can't be logged to:")
+		for _, key := range problemKeys {
+			problem := problems[key]
+			logger.Error("  - %s %s", problem.Issue, problem.Reason)
+			suggestions := SuggestIssues(problem.Issue, candidates)
+			if len(suggestions) > 0 {
+				logger.Error("    did you mean %s?", strings.Join(suggestions, ", "))
+			}
+			report.Invalid = append(report.Invalid, ReportProblem{Issue: problem.Issue, Reason: problem.Reason, Suggestions: suggestions})
+		}
+
+		if !cmdLineFlags["partial"] {
+			logger.Error("Nothing was posted. Fix the entries, or pass --partial to post the rest.")
+			exit(ExitValidation)
+		}
+		days = RemoveProblemEntries(days, problems)
+		if len(days) == 0 {
+			logger.Error("No valid entries left to post.")
+			exit(ExitValidation)
+		}
+		logger.Warn("Posting the remaining entries (--partial).")
+	}
+
+	// Post worklogs, recording each one in the ledger
 	var successes []WorklogResult
-	var failures []WorklogResult
+	submission := NewSubmissionID()
+	report.Submission = submission
+	failedRun := FailedRun{Submission: submission, Timestamp: time.Now(), Profile: settings.Profile()}
 
-	for _, entry := range entries {
-		result := PostWorklog(settings, logger, entry.Issue, entry.Seconds, startedISO)
-		if result.Success {
-			successes = append(successes, result)
-		} else {
-			failures = append(failures, result)
+posting:
+	for _, day := range days {
+		for _, entry := range day.Entries {
+			result := PostWorklog(settings, logger, entry)
+			if result.Success {
+				if len(successes) == 0 {
+					RecordSubmission(settings, logger, submission, rawInput)
+				}
+				RecordPosted(settings, logger, submission, result)
+				successes = append(successes, result)
+				report.AddPosted(entry, result)
+			} else {
+				failed := NewFailedEntry(entry, result)
+				failedRun.Entries = append(failedRun.Entries, failed)
+				report.AddFailed(failed)
+				if cmdLineFlags["atomic"] {
+					break posting
+				}
+			}
+		}
+	}
+
+	// Replace the failures saved by the previous run even when none failed, so retry never
+	// re-posts entries that have been posted since. Atomic runs roll back, leaving nothing to retry.
+	savedRun := failedRun
+	if cmdLineFlags["atomic"] {
+		savedRun.Entries = nil
+	}
+	saveErr := SaveFailedRun(savedRun)
+	if saveErr != nil {
+		logger.Warn("Failed to save failed entries for retry: %v", saveErr)
+	}
+
+	// In atomic mode a failure undoes everything posted in this run
+	if cmdLineFlags["atomic"] && len(failedRun.Entries) > 0 {
+		failed := failedRun.Entries[0]
+		logger.Error("%s %s failed: %s\n    %s", failed.Entry.Date, failed.Entry.Issue, describeFailure(failed), failed.Reason)
+
+		if len(successes) == 0 {
+			logger.Error("Nothing was posted.")
+			exit(ExitCodeForFailures(0, failedRun.Entries))
+		}
+
+		logger.Info("Rolling back %d worklog(s) posted in this run (--atomic):", len(successes))
+		orphans := RollbackWorklogs(settings, logger, submission, successes)
+		report.MarkRolledBack(orphans)
+		if len(orphans) > 0 {
+			logger.Error("%d worklog(s) could not be rolled back and are left in Jira:", len(orphans))
+			for _, orphan := range orphans {
+				logger.Error("  - orphan: %s worklog %s (%s on %s)", orphan.Issue, orphan.ID, FormatSeconds(orphan.Seconds), orphan.Date)
+			}
+			logger.Error("Delete them in Jira or with 'jira-worklogger undo %s'.", submission)
+			exit(ExitPartial)
 		}
+		logger.Info("Rolled back every worklog, nothing is left logged from this run.")
+		exit(ExitCodeForFailures(0, failedRun.Entries))
 	}
 
 	// Report results
@@ -253,24 +750,44 @@ func main() {
 		hours := totalSeconds / 3600
 		minutes := (totalSeconds % 3600) / 60
 
-		logger.Info("Posted %d worklogs (%dh %dm).", len(successes), hours, minutes)
-		for _, success := range successes {
-			h := success.Seconds / 3600
-			m := (success.Seconds % 3600) / 60
-			logger.Info("  - %s: %dh%dm", success.Issue, h, m)
+		logger.Info("Posted %d worklogs (%dh %dm) as submission %s.", len(successes), hours, minutes, submission)
+		for _, day := range days {
+			daySeconds := 0
+			for _, success := range successes {
+				if success.Date == day.Date {
+					daySeconds += success.Seconds
+				}
+			}
+			if daySeconds == 0 {
+				continue
+			}
+
+			logger.Info("%s (%dh%dm):", day.Date, daySeconds/3600, (daySeconds%3600)/60)
+			for _, success := range successes {
+				if success.Date != day.Date {
+					continue
+				}
+				h := success.Seconds / 3600
+				m := (success.Seconds % 3600) / 60
+				logger.Info("  - %s: %dh%dm", success.Issue, h, m)
+			}
 		}
 	}
 
-	if len(failures) > 0 {
+	if len(failedRun.Entries) > 0 {
 		logger.Error("Some entries failed:")
-		for _, failure := range failures {
-			// Limit the response body to 500 characters
-			responseBody := failure.Body
-			if len(responseBody) > 500 {
-				responseBody = responseBody[:500]
+		retryable := 0
+		for _, failed := range failedRun.Entries {
+			logger.Error("  - %s %s: %s\n    %s", failed.Entry.Date, failed.Entry.Issue, describeFailure(failed), failed.Reason)
+			if failed.Retryable {
+				retryable++
 			}
-			logger.Error("  - %s: HTTP %d\n    %s", failure.Issue, failure.Code, responseBody)
 		}
-		os.Exit(1)
+
+		// The failures were saved above so they can be re-posted without rebuilding the input
+		if saveErr == nil && retryable > 0 {
+			logger.Info("Run 'jira-worklogger retry' to re-post the %d retryable entries.", retryable)
+		}
 	}
+	exit(ExitCodeForFailures(len(successes), failedRun.Entries))
 }
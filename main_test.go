@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liam-witterick/jira-worklogger/mockjira"
+)
+
+// newTestSettings loads settings from a YAML config like the commands do, pointed at a mock Jira
+// serving the default fixture with the given failures injected. State is kept in a temporary directory.
+func newTestSettings(t *testing.T, config string, failures ...mockjira.Failure) *Settings {
+	t.Helper()
+	fixture := mockjira.DefaultFixture()
+	fixture.Failures = append(fixture.Failures, failures...)
+	server := httptest.NewServer(mockjira.NewServer(fixture))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "worklog_config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WORKLOG_CONFIG", path)
+	t.Setenv("WORKLOG_STATE_DIR", t.TempDir())
+	t.Setenv("JIRA_BASE_URL", server.URL)
+	t.Setenv("JIRA_EMAIL", "jane.doe@example.com")
+	t.Setenv("JIRA_API_TOKEN", "secret")
+	t.Setenv("JIRA_API_VERSION", "3")
+	t.Setenv("TIMEZONE", "Europe/London")
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("LOG_FILE", "")
+
+	settings, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+	return settings
+}
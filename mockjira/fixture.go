@@ -0,0 +1,159 @@
+// Package mockjira is an in-memory fake of the Jira REST API, serving the endpoints used by
+// jira-worklogger in both API v2 and v3 flavours, for demos and integration tests.
+package mockjira
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default_fixture.yaml
+var defaultFixture []byte
+
+// Fixture is the initial state of the server
+type Fixture struct {
+	User     FixtureUser    `yaml:"user"`
+	Auth     *FixtureAuth   `yaml:"auth"`
+	Issues   []FixtureIssue `yaml:"issues"`
+	Failures []Failure      `yaml:"failures"`
+}
+
+// FixtureUser is the authenticated user
+type FixtureUser struct {
+	AccountID   string `yaml:"account_id"`
+	Name        string `yaml:"name"`
+	DisplayName string `yaml:"display_name"`
+	Email       string `yaml:"email"`
+	TimeZone    string `yaml:"time_zone"`
+}
+
+// FixtureAuth are the only credentials accepted when set
+type FixtureAuth struct {
+	Username string `yaml:"username"`
+	Token    string `yaml:"token"`
+}
+
+// FixtureIssue is an issue with its worklogs
+type FixtureIssue struct {
+	Key      string           `yaml:"key"`
+	Summary  string           `yaml:"summary"`
+	Type     string           `yaml:"type"`
+	Status   string           `yaml:"status"`
+	Parent   string           `yaml:"parent"`
+	EpicLink string           `yaml:"epic_link"`
+	Assignee string           `yaml:"assignee"` // Account ID or name of the assignee
+	Worklogs []FixtureWorklog `yaml:"worklogs"`
+}
+
+// FixtureWorklog is a worklog of an issue
+type FixtureWorklog struct {
+	Author  string `yaml:"author"`  // Account ID or name, defaults to the user
+	Started string `yaml:"started"` // Jira timestamp or RFC 3339
+	Seconds int    `yaml:"seconds"`
+	Comment string `yaml:"comment"`
+}
+
+// Failure makes requests fail with a status code
+type Failure struct {
+	Method string `yaml:"method"` // Empty or "*" for any method
+	Path   string `yaml:"path"`   // Glob matched against the path after /rest/api/{version}
+	Status int    `yaml:"status"`
+	Count  int    `yaml:"count"` // Number of requests to fail, 0 for all of them
+	Body   string `yaml:"body"`  // Response body, defaults to a Jira style error
+}
+
+// DefaultFixture returns the fixture served when no other is given
+func DefaultFixture() *Fixture {
+	fixture, err := ParseFixture(defaultFixture)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default fixture: %v", err))
+	}
+	return fixture
+}
+
+// LoadFixture reads a fixture from a YAML file
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %v", err)
+	}
+	fixture, err := ParseFixture(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %v", path, err)
+	}
+	return fixture, nil
+}
+
+// ParseFixture parses and validates a YAML fixture
+func ParseFixture(data []byte) (*Fixture, error) {
+	var fixture Fixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, err
+	}
+
+	if fixture.User.AccountID == "" && fixture.User.Name == "" {
+		return nil, fmt.Errorf("user needs an account_id or a name")
+	}
+	seen := map[string]bool{}
+	for i := range fixture.Issues {
+		issue := &fixture.Issues[i]
+		issue.Key = strings.ToUpper(issue.Key)
+		if issue.Key == "" || !strings.Contains(issue.Key, "-") {
+			return nil, fmt.Errorf("issue %d: invalid key %q", i+1, issue.Key)
+		}
+		if seen[issue.Key] {
+			return nil, fmt.Errorf("duplicate issue %s", issue.Key)
+		}
+		seen[issue.Key] = true
+		for j := range issue.Worklogs {
+			started, err := parseStarted(issue.Worklogs[j].Started)
+			if err != nil {
+				return nil, fmt.Errorf("%s worklog %d: invalid started %q", issue.Key, j+1, issue.Worklogs[j].Started)
+			}
+			issue.Worklogs[j].Started = started.Format(jira.TimeLayout)
+		}
+	}
+	for i, failure := range fixture.Failures {
+		if failure.Status < 400 || failure.Status > 599 {
+			return nil, fmt.Errorf("failure %d: status must be between 400 and 599", i+1)
+		}
+	}
+	return &fixture, nil
+}
+
+// ParseFailure parses a failure written as METHOD:PATH:STATUS[:COUNT], e.g. POST:/issue/*/worklog:429:2
+func ParseFailure(spec string) (Failure, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 3 || len(parts) > 4 {
+		return Failure{}, fmt.Errorf("invalid failure %q, expected METHOD:PATH:STATUS[:COUNT]", spec)
+	}
+	failure := Failure{Method: strings.ToUpper(parts[0]), Path: parts[1]}
+	status, err := strconv.Atoi(parts[2])
+	if err != nil || status < 400 || status > 599 {
+		return Failure{}, fmt.Errorf("invalid failure %q, status must be between 400 and 599", spec)
+	}
+	failure.Status = status
+	if len(parts) == 4 {
+		count, err := strconv.Atoi(parts[3])
+		if err != nil || count < 0 {
+			return Failure{}, fmt.Errorf("invalid failure %q, count must be a number", spec)
+		}
+		failure.Count = count
+	}
+	return failure, nil
+}
+
+// parseStarted parses a worklog start in the Jira format or RFC 3339
+func parseStarted(value string) (time.Time, error) {
+	if started, err := time.Parse(jira.TimeLayout, value); err == nil {
+		return started, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,192 @@
+package mockjira
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The mock understands JQL queries made of clauses joined with AND, which covers the queries
+// jira-worklogger sends. OR, functions other than currentUser() and ORDER BY sorting are not
+// supported; ORDER BY is ignored.
+
+var (
+	orderByPattern = regexp.MustCompile(`(?i)\s+ORDER\s+BY\s+.*$`)
+	andPattern     = regexp.MustCompile(`(?i)\s+AND\s+`)
+	orPattern      = regexp.MustCompile(`(?i)\s+OR\s+`)
+	clausePattern  = regexp.MustCompile(`^(\w+)\s*(!=|>=|<=|=|>|<|~|(?i:not\s+in)\b|(?i:in)\b)\s*(.+)$`)
+)
+
+// clause is a single condition of a JQL query
+type clause struct {
+	field  string
+	op     string
+	values []string
+}
+
+// jqlError is a query Jira would reject with HTTP 400
+type jqlError struct {
+	messages []string
+}
+
+func (e *jqlError) Error() string {
+	return strings.Join(e.messages, " ")
+}
+
+// parseJQL splits a query into clauses
+func parseJQL(jql string) ([]clause, error) {
+	jql = strings.TrimSpace(orderByPattern.ReplaceAllString(jql, ""))
+	if jql == "" {
+		return nil, nil
+	}
+	if orPattern.MatchString(jql) {
+		return nil, &jqlError{[]string{"The mock Jira server does not support OR in JQL queries."}}
+	}
+
+	clauses := []clause{}
+	for _, part := range andPattern.Split(jql, -1) {
+		match := clausePattern.FindStringSubmatch(strings.TrimSpace(part))
+		if match == nil {
+			return nil, &jqlError{[]string{fmt.Sprintf("Error in the JQL Query: unable to parse %q.", part)}}
+		}
+		op := strings.ToLower(strings.Join(strings.Fields(match[2]), " "))
+		value := strings.TrimSpace(match[3])
+
+		var values []string
+		if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				values = append(values, unquote(strings.TrimSpace(item)))
+			}
+		} else {
+			values = []string{unquote(value)}
+		}
+		clauses = append(clauses, clause{field: match[1], op: op, values: values})
+	}
+	return clauses, nil
+}
+
+// unquote removes the single or double quotes around a JQL value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// matchesAny compares a value with the clause values, case-insensitively
+func (c clause) matchesAny(value string) bool {
+	for _, v := range c.values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare applies an equality or list operator to a value
+func (c clause) compare(value string) (bool, error) {
+	switch c.op {
+	case "=", "in":
+		return c.matchesAny(value), nil
+	case "!=", "not in":
+		return !c.matchesAny(value), nil
+	}
+	return false, c.unsupported()
+}
+
+// unsupported returns the error Jira gives for an operator a field does not support
+func (c clause) unsupported() error {
+	return &jqlError{[]string{fmt.Sprintf("The operator '%s' is not supported by the '%s' field.", c.op, c.field)}}
+}
+
+// checkKeys reports keys Jira would reject because no such issue exists
+func (s *Server) checkKeys(clauses []clause) error {
+	messages := []string{}
+	for _, c := range clauses {
+		if !strings.EqualFold(c.field, "key") && !strings.EqualFold(c.field, "issuekey") {
+			continue
+		}
+		if c.op != "=" && c.op != "in" {
+			continue
+		}
+		for _, key := range c.values {
+			if s.issue(key) == nil {
+				messages = append(messages, fmt.Sprintf("An issue with key '%s' does not exist for field 'key'.", key))
+			}
+		}
+	}
+	if len(messages) > 0 {
+		return &jqlError{messages}
+	}
+	return nil
+}
+
+// matches evaluates a clause against an issue
+func (s *Server) matches(issue *issueState, c clause) (bool, error) {
+	switch strings.ToLower(c.field) {
+	case "key", "issuekey":
+		return c.compare(issue.key)
+	case "summary":
+		if c.op != "~" {
+			return false, c.unsupported()
+		}
+		return strings.Contains(strings.ToLower(issue.summary), strings.ToLower(c.values[0])), nil
+	case "status":
+		return c.compare(issue.status)
+	case "issuetype", "type":
+		return c.compare(issue.issueType)
+	case "project":
+		return c.compare(issue.project())
+	case "parent":
+		return c.compare(issue.parent)
+	case "assignee":
+		return c.compare(s.resolveUser(issue.assignee, c))
+	case "worklogauthor":
+		if c.op != "=" && c.op != "in" {
+			return false, c.unsupported()
+		}
+		for _, worklog := range issue.worklogs {
+			if c.matchesAny(s.resolveUser(worklog.author, c)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "worklogdate":
+		for _, worklog := range issue.worklogs {
+			ok, err := compareDate(worklog.started[:10], c)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	return false, &jqlError{[]string{fmt.Sprintf("Field '%s' does not exist or you do not have permission to view it.", c.field)}}
+}
+
+// resolveUser returns the clause value that refers to the user, so currentUser() matches
+func (s *Server) resolveUser(id string, c clause) string {
+	if id != "" && s.user.Matches(id) && c.matchesAny("currentUser()") {
+		return "currentUser()"
+	}
+	return id
+}
+
+// compareDate applies a comparison operator to YYYY-MM-DD dates
+func compareDate(date string, c clause) (bool, error) {
+	value := c.values[0]
+	switch c.op {
+	case "=":
+		return date == value, nil
+	case "!=":
+		return date != value, nil
+	case ">=":
+		return date >= value, nil
+	case "<=":
+		return date <= value, nil
+	case ">":
+		return date > value, nil
+	case "<":
+		return date < value, nil
+	}
+	return false, c.unsupported()
+}
@@ -0,0 +1,660 @@
+package mockjira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+)
+
+// Logger receives one line per request served
+type Logger interface {
+	Info(format string, args ...interface{})
+}
+
+// Server is an in-memory Jira. It implements http.Handler and serves the REST API under
+// /rest/api/2 (Server/Data Center flavour) and /rest/api/3 (Cloud flavour).
+type Server struct {
+	Logger Logger // Optional
+
+	mu            sync.Mutex
+	user          jira.User
+	auth          *FixtureAuth
+	issues        []*issueState
+	failures      []*Failure
+	nextWorklogID int
+}
+
+// issueState is an issue held by the server
+type issueState struct {
+	id        string
+	key       string
+	summary   string
+	issueType string
+	status    string
+	parent    string
+	epicLink  string
+	assignee  string
+	worklogs  []*worklogState
+}
+
+// worklogState is a worklog held by the server
+type worklogState struct {
+	id         string
+	author     string
+	started    string
+	seconds    int
+	comment    string
+	visibility *jira.Visibility
+}
+
+// project returns the project key of the issue
+func (i *issueState) project() string {
+	return i.key[:strings.LastIndex(i.key, "-")]
+}
+
+// NewServer returns a server holding the issues and worklogs of the fixture
+func NewServer(fixture *Fixture) *Server {
+	s := &Server{
+		user: jira.User{
+			AccountID:    fixture.User.AccountID,
+			Name:         fixture.User.Name,
+			Key:          fixture.User.Name,
+			EmailAddress: fixture.User.Email,
+			DisplayName:  fixture.User.DisplayName,
+			Active:       true,
+			TimeZone:     fixture.User.TimeZone,
+		},
+		auth:          fixture.Auth,
+		nextWorklogID: 10000,
+	}
+
+	for i, fixtureIssue := range fixture.Issues {
+		issue := &issueState{
+			id:        strconv.Itoa(10000 + i),
+			key:       fixtureIssue.Key,
+			summary:   fixtureIssue.Summary,
+			issueType: fixtureIssue.Type,
+			status:    fixtureIssue.Status,
+			parent:    strings.ToUpper(fixtureIssue.Parent),
+			epicLink:  strings.ToUpper(fixtureIssue.EpicLink),
+			assignee:  fixtureIssue.Assignee,
+		}
+		if issue.issueType == "" {
+			issue.issueType = "Task"
+		}
+		if issue.status == "" {
+			issue.status = "Open"
+		}
+		for _, fixtureWorklog := range fixtureIssue.Worklogs {
+			author := fixtureWorklog.Author
+			if author == "" {
+				author = s.user.ID()
+			}
+			issue.worklogs = append(issue.worklogs, &worklogState{
+				id:      s.newWorklogID(),
+				author:  author,
+				started: fixtureWorklog.Started,
+				seconds: fixtureWorklog.Seconds,
+				comment: fixtureWorklog.Comment,
+			})
+		}
+		s.issues = append(s.issues, issue)
+	}
+
+	for _, failure := range fixture.Failures {
+		s.InjectFailure(failure)
+	}
+	return s
+}
+
+// InjectFailure makes the requests matching the failure fail, after the failures injected before
+func (s *Server) InjectFailure(failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &failure)
+}
+
+// Worklogs returns a copy of the worklogs of an issue, for assertions in tests
+func (s *Server) Worklogs(issueKey string) []jira.Worklog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	issue := s.issue(issueKey)
+	if issue == nil {
+		return nil
+	}
+	worklogs := []jira.Worklog{}
+	for _, worklog := range issue.worklogs {
+		worklogs = append(worklogs, s.renderWorklog("2", issue, worklog))
+	}
+	return worklogs
+}
+
+func (s *Server) newWorklogID() string {
+	s.nextWorklogID++
+	return strconv.Itoa(s.nextWorklogID)
+}
+
+// issue returns the issue with a key, nil when there is none
+func (s *Server) issue(key string) *issueState {
+	for _, issue := range s.issues {
+		if strings.EqualFold(issue.key, key) {
+			return issue
+		}
+	}
+	return nil
+}
+
+// statusRecorder keeps the status code of a response for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// ServeHTTP serves a REST API request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	s.mu.Lock()
+	s.serve(recorder, r)
+	s.mu.Unlock()
+
+	if s.Logger != nil {
+		s.Logger.Info("%s %s -> %d", r.Method, r.URL.RequestURI(), recorder.status)
+	}
+}
+
+// serve routes a request, with the lock held
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	var version, apiPath string
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/rest/api/2/"):
+		version, apiPath = "2", strings.TrimPrefix(r.URL.Path, "/rest/api/2")
+	case strings.HasPrefix(r.URL.Path, "/rest/api/3/"):
+		version, apiPath = "3", strings.TrimPrefix(r.URL.Path, "/rest/api/3")
+	default:
+		writeError(w, http.StatusNotFound, []string{"Not found."}, nil)
+		return
+	}
+
+	if s.injectFailure(w, r.Method, apiPath) {
+		return
+	}
+	if s.auth != nil {
+		username, token, ok := r.BasicAuth()
+		if !ok || username != s.auth.Username || token != s.auth.Token {
+			writeError(w, http.StatusUnauthorized, []string{"You are not authenticated. Authentication required to perform this operation."}, nil)
+			return
+		}
+	}
+
+	segments := strings.Split(strings.Trim(apiPath, "/"), "/")
+	switch {
+	case apiPath == "/myself" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.user)
+	case apiPath == "/serverInfo" && r.Method == "GET":
+		s.serveServerInfo(w, r, version)
+	case apiPath == "/field" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, fieldList)
+	case apiPath == "/search" && (r.Method == "GET" || r.Method == "POST"):
+		s.serveSearch(w, r, version, false)
+	case apiPath == "/search/jql" && version == "3" && (r.Method == "GET" || r.Method == "POST"):
+		s.serveSearch(w, r, version, true)
+	case len(segments) == 3 && segments[0] == "issue" && segments[2] == "worklog":
+		s.serveWorklogs(w, r, version, segments[1])
+	case len(segments) == 4 && segments[0] == "issue" && segments[2] == "worklog":
+		s.serveWorklog(w, r, version, segments[1], segments[3])
+	default:
+		writeError(w, http.StatusNotFound, []string{fmt.Sprintf("No mock for %s %s.", r.Method, r.URL.Path)}, nil)
+	}
+}
+
+// injectFailure answers the request with the first matching failure, reporting whether it did
+func (s *Server) injectFailure(w http.ResponseWriter, method, apiPath string) bool {
+	for i, failure := range s.failures {
+		if failure.Method != "" && failure.Method != "*" && !strings.EqualFold(failure.Method, method) {
+			continue
+		}
+		if ok, _ := path.Match(failure.Path, apiPath); !ok {
+			continue
+		}
+
+		if failure.Count > 0 {
+			failure.Count--
+			if failure.Count == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+		if failure.Status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		if failure.Body != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(failure.Status)
+			fmt.Fprint(w, failure.Body)
+		} else {
+			writeError(w, failure.Status, []string{fmt.Sprintf("Injected failure: %s.", http.StatusText(failure.Status))}, nil)
+		}
+		return true
+	}
+	return false
+}
+
+// serveServerInfo describes the server, as Cloud for v3 and Data Center for v2
+func (s *Server) serveServerInfo(w http.ResponseWriter, r *http.Request, version string) {
+	info := map[string]interface{}{
+		"baseUrl":        "http://" + r.Host,
+		"serverTitle":    "Mock Jira",
+		"deploymentType": "Cloud",
+		"version":        "1001.0.0-SNAPSHOT",
+		"versionNumbers": []int{1001, 0, 0},
+		"serverTime":     time.Now().Format(jira.TimeLayout),
+	}
+	if version == "2" {
+		info["deploymentType"] = "Server"
+		info["version"] = "9.12.0"
+		info["versionNumbers"] = []int{9, 12, 0}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// fieldList are the fields returned by /field
+var fieldList = []map[string]interface{}{
+	{"id": "summary", "key": "summary", "name": "Summary", "custom": false, "navigable": true, "searchable": true},
+	{"id": "issuetype", "key": "issuetype", "name": "Issue Type", "custom": false, "navigable": true, "searchable": true},
+	{"id": "status", "key": "status", "name": "Status", "custom": false, "navigable": true, "searchable": true},
+	{"id": "project", "key": "project", "name": "Project", "custom": false, "navigable": true, "searchable": true},
+	{"id": "parent", "key": "parent", "name": "Parent", "custom": false, "navigable": true, "searchable": true},
+	{"id": "assignee", "key": "assignee", "name": "Assignee", "custom": false, "navigable": true, "searchable": true},
+	{"id": "worklog", "key": "worklog", "name": "Log Work", "custom": false, "navigable": false, "searchable": true},
+	{"id": "customfield_10014", "key": "customfield_10014", "name": "Epic Link", "custom": true, "navigable": true, "searchable": true,
+		"schema": map[string]interface{}{"type": "any", "custom": "com.pyxis.greenhopper.jira:gh-epic-link", "customId": 10014}},
+}
+
+// searchRequest holds the parameters of a search, from the query string or the JSON body
+type searchRequest struct {
+	JQL           string   `json:"jql"`
+	Fields        []string `json:"fields"`
+	StartAt       int      `json:"startAt"`
+	MaxResults    int      `json:"maxResults"`
+	NextPageToken string   `json:"nextPageToken"`
+}
+
+// serveSearch runs a JQL search, paged with startAt (search) or nextPageToken (search/jql)
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, version string, tokenPaging bool) {
+	var req searchRequest
+	if r.Method == "POST" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, []string{"Invalid request payload."}, nil)
+			return
+		}
+	} else {
+		query := r.URL.Query()
+		req.JQL = query.Get("jql")
+		if fields := query.Get("fields"); fields != "" {
+			req.Fields = strings.Split(fields, ",")
+		}
+		req.StartAt, _ = strconv.Atoi(query.Get("startAt"))
+		req.MaxResults, _ = strconv.Atoi(query.Get("maxResults"))
+		req.NextPageToken = query.Get("nextPageToken")
+	}
+	if req.MaxResults <= 0 || req.MaxResults > 100 {
+		req.MaxResults = 50
+	}
+	if tokenPaging {
+		req.StartAt, _ = strconv.Atoi(req.NextPageToken)
+	}
+
+	matched, err := s.search(req.JQL)
+	if err != nil {
+		var jqlErr *jqlError
+		if errors.As(err, &jqlErr) {
+			writeError(w, http.StatusBadRequest, jqlErr.messages, nil)
+		} else {
+			writeError(w, http.StatusInternalServerError, []string{err.Error()}, nil)
+		}
+		return
+	}
+
+	start := req.StartAt
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + req.MaxResults
+	if end > len(matched) {
+		end = len(matched)
+	}
+	fields := requestedFields(req.Fields)
+	issues := []jira.Issue{}
+	for _, issue := range matched[start:end] {
+		issues = append(issues, s.renderIssue(r, version, issue, fields))
+	}
+
+	if tokenPaging {
+		page := map[string]interface{}{"issues": issues, "isLast": end >= len(matched)}
+		if end < len(matched) {
+			page["nextPageToken"] = strconv.Itoa(end)
+		}
+		writeJSON(w, http.StatusOK, page)
+		return
+	}
+	writeJSON(w, http.StatusOK, jira.SearchPage{StartAt: start, MaxResults: req.MaxResults, Total: len(matched), Issues: issues})
+}
+
+// search returns the issues matching a JQL query
+func (s *Server) search(jql string) ([]*issueState, error) {
+	clauses, err := parseJQL(jql)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkKeys(clauses); err != nil {
+		return nil, err
+	}
+
+	matched := []*issueState{}
+	for _, issue := range s.issues {
+		ok := true
+		for _, c := range clauses {
+			if ok, err = s.matches(issue, c); err != nil {
+				return nil, err
+			} else if !ok {
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, issue)
+		}
+	}
+	return matched, nil
+}
+
+// requestedFields returns the set of requested fields, nil for all of them
+func requestedFields(fields []string) map[string]bool {
+	set := map[string]bool{}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "*all" || field == "*navigable" {
+			return nil
+		}
+		set[field] = true
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
+
+// renderIssue returns an issue with the requested fields
+func (s *Server) renderIssue(r *http.Request, version string, issue *issueState, fields map[string]bool) jira.Issue {
+	wanted := func(field string) bool {
+		return fields == nil || fields[field]
+	}
+
+	rendered := jira.Issue{
+		ID:   issue.id,
+		Key:  issue.key,
+		Self: fmt.Sprintf("http://%s/rest/api/%s/issue/%s", r.Host, version, issue.id),
+	}
+	if wanted("summary") {
+		rendered.Fields.Summary = issue.summary
+	}
+	if wanted("issuetype") {
+		rendered.Fields.IssueType = &jira.IssueType{Name: issue.issueType}
+	}
+	if wanted("status") {
+		rendered.Fields.Status = &jira.Status{Name: issue.status}
+	}
+	if wanted("project") {
+		rendered.Fields.Project = &jira.Project{Key: issue.project()}
+	}
+	if wanted("parent") && issue.parent != "" {
+		rendered.Fields.Parent = &jira.Parent{Key: issue.parent}
+		if parent := s.issue(issue.parent); parent != nil {
+			rendered.Fields.Parent.ID = parent.id
+			rendered.Fields.Parent.Fields = &jira.Fields{
+				Summary:   parent.summary,
+				IssueType: &jira.IssueType{Name: parent.issueType},
+				Status:    &jira.Status{Name: parent.status},
+			}
+		}
+	}
+	if wanted("customfield_10014") {
+		rendered.Fields.EpicLink = issue.epicLink
+	}
+	return rendered
+}
+
+// renderWorklog returns a worklog with the comment in the format of the API version
+func (s *Server) renderWorklog(version string, issue *issueState, worklog *worklogState) jira.Worklog {
+	author := &jira.User{AccountID: worklog.author, Name: worklog.author, DisplayName: worklog.author}
+	if s.user.Matches(worklog.author) {
+		author = &s.user
+	}
+
+	rendered := jira.Worklog{
+		ID:               worklog.id,
+		IssueID:          issue.id,
+		Author:           author,
+		UpdateAuthor:     author,
+		Started:          worklog.started,
+		TimeSpent:        formatTimeSpent(worklog.seconds),
+		TimeSpentSeconds: worklog.seconds,
+		Visibility:       worklog.visibility,
+	}
+	if worklog.comment != "" {
+		if version == "3" {
+			rendered.Comment, _ = json.Marshal(adfDocument(worklog.comment))
+		} else {
+			rendered.Comment, _ = json.Marshal(worklog.comment)
+		}
+	}
+	return rendered
+}
+
+// serveWorklogs lists the worklogs of an issue or adds one
+func (s *Server) serveWorklogs(w http.ResponseWriter, r *http.Request, version, issueKey string) {
+	issue := s.issue(issueKey)
+	if issue == nil {
+		writeError(w, http.StatusNotFound, []string{"Issue does not exist or you do not have permission to see it."}, nil)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		query := r.URL.Query()
+		startAt, _ := strconv.Atoi(query.Get("startAt"))
+		maxResults, err := strconv.Atoi(query.Get("maxResults"))
+		if err != nil || maxResults <= 0 || maxResults > 5000 {
+			maxResults = 5000
+		}
+		startedAfter, _ := strconv.ParseInt(query.Get("startedAfter"), 10, 64)
+		startedBefore, _ := strconv.ParseInt(query.Get("startedBefore"), 10, 64)
+
+		matched := []*worklogState{}
+		for _, worklog := range issue.worklogs {
+			started, _ := time.Parse(jira.TimeLayout, worklog.started)
+			if startedAfter > 0 && started.UnixMilli() < startedAfter {
+				continue
+			}
+			if startedBefore > 0 && started.UnixMilli() >= startedBefore {
+				continue
+			}
+			matched = append(matched, worklog)
+		}
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].started < matched[j].started })
+
+		page := jira.WorklogPage{StartAt: startAt, MaxResults: maxResults, Total: len(matched), Worklogs: []jira.Worklog{}}
+		for i := startAt; i < len(matched) && i < startAt+maxResults; i++ {
+			page.Worklogs = append(page.Worklogs, s.renderWorklog(version, issue, matched[i]))
+		}
+		writeJSON(w, http.StatusOK, page)
+
+	case "POST":
+		worklog := &worklogState{author: s.user.ID()}
+		if !s.applyWorklog(w, r, version, worklog, true) {
+			return
+		}
+		worklog.id = s.newWorklogID()
+		issue.worklogs = append(issue.worklogs, worklog)
+		writeJSON(w, http.StatusCreated, s.renderWorklog(version, issue, worklog))
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, []string{"Method not allowed."}, nil)
+	}
+}
+
+// serveWorklog gets, updates or deletes a worklog
+func (s *Server) serveWorklog(w http.ResponseWriter, r *http.Request, version, issueKey, worklogID string) {
+	issue := s.issue(issueKey)
+	if issue == nil {
+		writeError(w, http.StatusNotFound, []string{"Issue does not exist or you do not have permission to see it."}, nil)
+		return
+	}
+	index := -1
+	for i, worklog := range issue.worklogs {
+		if worklog.id == worklogID {
+			index = i
+		}
+	}
+	if index < 0 {
+		writeError(w, http.StatusNotFound, []string{fmt.Sprintf("Cannot find worklog with id: %s.", worklogID)}, nil)
+		return
+	}
+	worklog := issue.worklogs[index]
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, s.renderWorklog(version, issue, worklog))
+	case "PUT":
+		updated := *worklog
+		if !s.applyWorklog(w, r, version, &updated, false) {
+			return
+		}
+		*worklog = updated
+		writeJSON(w, http.StatusOK, s.renderWorklog(version, issue, worklog))
+	case "DELETE":
+		issue.worklogs = append(issue.worklogs[:index], issue.worklogs[index+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, []string{"Method not allowed."}, nil)
+	}
+}
+
+// applyWorklog validates a worklog request body and applies it, answering with HTTP 400 and
+// returning false when it is invalid. Creating requires the start and time spent.
+func (s *Server) applyWorklog(w http.ResponseWriter, r *http.Request, version string, worklog *worklogState, create bool) bool {
+	var body struct {
+		Started          *string          `json:"started"`
+		TimeSpentSeconds *int             `json:"timeSpentSeconds"`
+		Comment          json.RawMessage  `json:"comment"`
+		Visibility       *jira.Visibility `json:"visibility"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, []string{"Invalid request payload. Refer to the REST API documentation and try again."}, nil)
+		return false
+	}
+
+	fieldErrors := map[string]string{}
+	if body.Started != nil {
+		if _, err := time.Parse(jira.TimeLayout, *body.Started); err != nil {
+			fieldErrors["started"] = "Invalid date format. Please enter the date in the format \"yyyy-MM-dd'T'HH:mm:ss.SSSZ\"."
+		} else {
+			worklog.started = *body.Started
+		}
+	} else if create {
+		fieldErrors["started"] = "You must specify a start date."
+	}
+	if body.TimeSpentSeconds != nil {
+		if *body.TimeSpentSeconds <= 0 {
+			fieldErrors["timeLogged"] = "You must indicate the time spent working."
+		} else {
+			worklog.seconds = *body.TimeSpentSeconds
+		}
+	} else if create {
+		fieldErrors["timeLogged"] = "You must indicate the time spent working."
+	}
+	if len(body.Comment) > 0 && string(body.Comment) != "null" {
+		var text string
+		isText := json.Unmarshal(body.Comment, &text) == nil
+		switch {
+		case version == "3" && isText:
+			fieldErrors["comment"] = "Operation value must be an Atlassian Document (see the Atlassian Document Format)"
+		case version == "2" && !isText:
+			fieldErrors["comment"] = "Operation value must be a string"
+		default:
+			worklog.comment = (&jira.Worklog{Comment: body.Comment}).CommentText()
+		}
+	}
+	if body.Visibility != nil {
+		worklog.visibility = body.Visibility
+	}
+
+	if len(fieldErrors) > 0 {
+		writeError(w, http.StatusBadRequest, nil, fieldErrors)
+		return false
+	}
+	return true
+}
+
+// adfDocument wraps plain text in a single paragraph Atlassian Document Format document
+func adfDocument(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "doc",
+		"version": 1,
+		"content": []interface{}{
+			map[string]interface{}{
+				"type":    "paragraph",
+				"content": []interface{}{map[string]interface{}{"type": "text", "text": text}},
+			},
+		},
+	}
+}
+
+// formatTimeSpent formats seconds the way Jira shows time spent, e.g. "1h 30m"
+func formatTimeSpent(seconds int) string {
+	parts := []string{}
+	if h := seconds / 3600; h > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", h))
+	}
+	if m := seconds % 3600 / 60; m > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", m))
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		body = []byte(`{"errorMessages":["Failed to encode the response."],"errors":{}}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeError writes an error response in the Jira format
+func writeError(w http.ResponseWriter, status int, messages []string, fieldErrors map[string]string) {
+	if messages == nil {
+		messages = []string{}
+	}
+	if fieldErrors == nil {
+		fieldErrors = map[string]string{}
+	}
+	writeJSON(w, status, map[string]interface{}{"errorMessages": messages, "errors": fieldErrors})
+}
@@ -0,0 +1,307 @@
+package mockjira_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+	"github.com/liam-witterick/jira-worklogger/mockjira"
+)
+
+// newMockClient returns a client of an API version talking to a mock Jira serving the fixture
+func newMockClient(t *testing.T, version string, fixture *mockjira.Fixture) (jira.Client, *mockjira.Server, string) {
+	t.Helper()
+	server := mockjira.NewServer(fixture)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client, err := jira.New(jira.Config{BaseURL: httpServer.URL + "/", Username: "jane.doe@example.com", Token: "secret", APIVersion: version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server, httpServer.URL
+}
+
+// searchKeys returns the keys of the issues matching a query
+func searchKeys(t *testing.T, client jira.Client, jql string) []string {
+	t.Helper()
+	issues, err := client.SearchIssues(context.Background(), jql, []string{"summary"})
+	if err != nil {
+		t.Fatalf("SearchIssues(%q): %v", jql, err)
+	}
+	keys := []string{}
+	for _, issue := range issues {
+		keys = append(keys, issue.Key)
+	}
+	return keys
+}
+
+func TestSearch(t *testing.T) {
+	// More issues than fit on a page of either API version
+	fixture := mockjira.DefaultFixture()
+	bulk := []string{}
+	for i := 1; i <= 150; i++ {
+		key := fmt.Sprintf("BULK-%d", i)
+		fixture.Issues = append(fixture.Issues, mockjira.FixtureIssue{Key: key, Summary: "Issue " + key, Parent: "PROJ-100"})
+		bulk = append(bulk, key)
+	}
+
+	for _, version := range []string{"2", "3"} {
+		t.Run("v"+version, func(t *testing.T) {
+			client, _, _ := newMockClient(t, version, fixture)
+
+			if keys := searchKeys(t, client, "project = BULK ORDER BY key"); !reflect.DeepEqual(keys, bulk) {
+				t.Errorf("expected BULK-1 to BULK-150 in order, got %v", keys)
+			}
+
+			queries := []struct {
+				jql  string
+				want []string
+			}{
+				{"assignee = currentUser() AND status != Done", []string{"PROJ-123", "PROJ-124", "OPS-7"}},
+				{"key in (proj-124, OPS-7)", []string{"PROJ-124", "OPS-7"}},
+				{"issuetype = Epic", []string{"PROJ-100"}},
+				{"project = PROJ AND status not in (Done, 'In Progress')", []string{}},
+				{"worklogAuthor = currentUser() AND worklogDate >= 2025-09-08 AND worklogDate <= \"2025-09-08\"", []string{"PROJ-124"}},
+				{"summary ~ review", []string{"PROJ-124"}},
+			}
+			for _, query := range queries {
+				if keys := searchKeys(t, client, query.jql); !reflect.DeepEqual(keys, query.want) {
+					t.Errorf("%s\n got %v\nwant %v", query.jql, keys, query.want)
+				}
+			}
+
+			// Queries Jira would reject
+			for _, jql := range []string{"key = NOPE-1", "key = PROJ-1 OR key = PROJ-2", "sprint = 7", "summary = review", "nonsense"} {
+				if _, err := client.SearchIssues(context.Background(), jql, nil); jira.StatusCode(err) != 400 {
+					t.Errorf("%s: expected HTTP 400, got %v", jql, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderedIssues(t *testing.T) {
+	for _, version := range []string{"2", "3"} {
+		t.Run("v"+version, func(t *testing.T) {
+			client, _, _ := newMockClient(t, version, mockjira.DefaultFixture())
+			issues, err := client.SearchIssues(context.Background(), "key in (PROJ-123, PROJ-124)", []string{"summary", "status", "parent", "customfield_10014"})
+			if err != nil || len(issues) != 2 {
+				t.Fatalf("SearchIssues = %+v, %v", issues, err)
+			}
+			meetings, review := issues[0].Fields, issues[1].Fields
+			if meetings.Summary != "Team meetings" || meetings.Status == nil || meetings.Status.Name != "In Progress" {
+				t.Errorf("unexpected fields %+v", meetings)
+			}
+			if meetings.Parent == nil || meetings.Parent.Key != "PROJ-100" || meetings.Parent.Fields == nil || meetings.Parent.Fields.Summary != "Platform improvements" {
+				t.Errorf("expected the parent epic with its summary, got %+v", meetings.Parent)
+			}
+			if review.EpicLink != "PROJ-100" {
+				t.Errorf("expected the epic link PROJ-100, got %q", review.EpicLink)
+			}
+		})
+	}
+}
+
+func TestMyself(t *testing.T) {
+	client, _, _ := newMockClient(t, "3", mockjira.DefaultFixture())
+	user, err := client.Myself(context.Background())
+	if err != nil {
+		t.Fatalf("Myself: %v", err)
+	}
+	if user.AccountID != "5b10ac8d82e05b22cc7d4ef5" || user.DisplayName != "Jane Doe" || !user.Matches("jdoe") {
+		t.Errorf("unexpected user %+v", user)
+	}
+}
+
+func TestWorklogs(t *testing.T) {
+	for _, version := range []string{"2", "3"} {
+		t.Run("v"+version, func(t *testing.T) {
+			client, server, _ := newMockClient(t, version, mockjira.DefaultFixture())
+			ctx := context.Background()
+
+			worklog := jira.NewWorklog{
+				Started:          "2025-09-09T17:00:00.000+0100",
+				TimeSpentSeconds: 5400,
+				Comment:          "Paged at night",
+				Visibility:       &jira.Visibility{Type: "role", Value: "Developers"},
+			}
+			created, err := client.AddWorklog(ctx, "OPS-7", worklog)
+			if err != nil {
+				t.Fatalf("AddWorklog: %v", err)
+			}
+			if created.ID == "" || created.TimeSpentSeconds != 5400 || created.TimeSpent != "1h 30m" || created.CommentText() != "Paged at night" {
+				t.Errorf("unexpected created worklog %+v", created)
+			}
+
+			stored := server.Worklogs("OPS-7")
+			if len(stored) != 1 || stored[0].CommentText() != "Paged at night" || !reflect.DeepEqual(stored[0].Visibility, worklog.Visibility) {
+				t.Fatalf("unexpected worklogs on the server: %+v", stored)
+			}
+			if stored[0].Author == nil || !stored[0].Author.Matches("5b10ac8d82e05b22cc7d4ef5") {
+				t.Errorf("expected the worklog to be authored by the user, got %+v", stored[0].Author)
+			}
+
+			after, _ := time.Parse(jira.TimeLayout, "2025-09-09T00:00:00.000+0100")
+			fetched, err := client.GetWorklogs(ctx, "OPS-7", after)
+			if err != nil || len(fetched) != 1 || fetched[0].ID != created.ID {
+				t.Errorf("GetWorklogs = %+v, %v", fetched, err)
+			}
+			if fetched, _ := client.GetWorklogs(ctx, "OPS-7", after.AddDate(0, 0, 1)); len(fetched) != 0 {
+				t.Errorf("expected no worklogs started after %s, got %+v", after.AddDate(0, 0, 1), fetched)
+			}
+
+			updated, err := client.UpdateWorklog(ctx, "OPS-7", created.ID, jira.NewWorklog{Started: worklog.Started, TimeSpentSeconds: 1800, Comment: "Shorter"})
+			if err != nil || updated.TimeSpentSeconds != 1800 || updated.CommentText() != "Shorter" {
+				t.Errorf("UpdateWorklog = %+v, %v", updated, err)
+			}
+
+			_, err = client.AddWorklog(ctx, "OPS-7", jira.NewWorklog{Started: "yesterday"})
+			var apiErr *jira.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || !strings.Contains(apiErr.Body, "started") || !strings.Contains(apiErr.Body, "timeLogged") {
+				t.Errorf("expected HTTP 400 with started and timeLogged errors, got %v", err)
+			}
+			if _, err := client.AddWorklog(ctx, "NOPE-1", worklog); !errors.Is(err, jira.ErrNotFound) {
+				t.Errorf("expected not found for an unknown issue, got %v", err)
+			}
+
+			if err := client.DeleteWorklog(ctx, "OPS-7", created.ID); err != nil {
+				t.Fatalf("DeleteWorklog: %v", err)
+			}
+			if err := client.DeleteWorklog(ctx, "OPS-7", created.ID); !errors.Is(err, jira.ErrNotFound) {
+				t.Errorf("expected not found for a deleted worklog, got %v", err)
+			}
+			if stored := server.Worklogs("OPS-7"); len(stored) != 0 {
+				t.Errorf("expected the worklog to be deleted, got %+v", stored)
+			}
+		})
+	}
+}
+
+func TestCommentFormat(t *testing.T) {
+	// Each API version only accepts its own comment representation
+	tests := []struct {
+		version string
+		comment string
+		status  int
+	}{
+		{"2", `"Fixed the build"`, 201},
+		{"2", `{"type":"doc","version":1,"content":[]}`, 400},
+		{"3", `{"type":"doc","version":1,"content":[]}`, 201},
+		{"3", `"Fixed the build"`, 400},
+	}
+	for _, tt := range tests {
+		_, _, url := newMockClient(t, tt.version, mockjira.DefaultFixture())
+		body := fmt.Sprintf(`{"started":"2025-09-09T17:00:00.000+0100","timeSpentSeconds":60,"comment":%s}`, tt.comment)
+		resp, err := http.Post(url+"/rest/api/"+tt.version+"/issue/OPS-7/worklog", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("v%s comment %s: HTTP %d, want %d", tt.version, tt.comment, resp.StatusCode, tt.status)
+		}
+	}
+}
+
+func TestFailureInjection(t *testing.T) {
+	fixture := mockjira.DefaultFixture()
+	fixture.Failures = []mockjira.Failure{{Method: "POST", Path: "/issue/*/worklog", Status: 500, Count: 2}}
+	client, server, _ := newMockClient(t, "3", fixture)
+	ctx := context.Background()
+	worklog := jira.NewWorklog{Started: "2025-09-09T17:00:00.000+0100", TimeSpentSeconds: 60}
+
+	// The failure only matches its method and path, and stops after its count
+	if _, err := client.GetWorklogs(ctx, "OPS-7", time.Time{}); err != nil {
+		t.Errorf("GetWorklogs: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.AddWorklog(ctx, "OPS-7", worklog); !errors.Is(err, jira.ErrServer) {
+			t.Errorf("request %d: expected the injected server error, got %v", i+1, err)
+		}
+	}
+	if _, err := client.AddWorklog(ctx, "OPS-7", worklog); err != nil {
+		t.Errorf("expected the failure to be used up, got %v", err)
+	}
+
+	// Failures without a count keep failing, with the given body
+	server.InjectFailure(mockjira.Failure{Path: "/myself", Status: 429, Body: `{"message":"Rate limit exceeded"}`})
+	for i := 0; i < 3; i++ {
+		_, err := client.Myself(ctx)
+		var apiErr *jira.APIError
+		if !errors.As(err, &apiErr) || !errors.Is(err, jira.ErrRateLimited) || apiErr.Body != `{"message":"Rate limit exceeded"}` {
+			t.Errorf("request %d: expected the injected rate limit, got %v", i+1, err)
+		}
+	}
+}
+
+func TestAuth(t *testing.T) {
+	fixture := mockjira.DefaultFixture()
+	fixture.Auth = &mockjira.FixtureAuth{Username: "jane.doe@example.com", Token: "other"}
+	client, _, _ := newMockClient(t, "2", fixture)
+	if _, err := client.Myself(context.Background()); !errors.Is(err, jira.ErrUnauthorized) {
+		t.Errorf("expected unauthorized with the wrong token, got %v", err)
+	}
+
+	fixture.Auth.Token = "secret"
+	client, _, _ = newMockClient(t, "2", fixture)
+	if _, err := client.Myself(context.Background()); err != nil {
+		t.Errorf("Myself with the right token: %v", err)
+	}
+}
+
+func TestParseFailure(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    mockjira.Failure
+		wantErr bool
+	}{
+		{spec: "post:/issue/*/worklog:429:2", want: mockjira.Failure{Method: "POST", Path: "/issue/*/worklog", Status: 429, Count: 2}},
+		{spec: "*:/myself:401", want: mockjira.Failure{Method: "*", Path: "/myself", Status: 401}},
+		{spec: "GET:/myself", wantErr: true},
+		{spec: "GET:/myself:200", wantErr: true},
+		{spec: "GET:/myself:500:-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := mockjira.ParseFailure(tt.spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFailure(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseFixture(t *testing.T) {
+	fixture, err := mockjira.ParseFixture([]byte(`
+user: {name: jdoe}
+issues:
+  - key: proj-1
+    worklogs:
+      - started: "2025-09-08T09:00:00+01:00"
+        seconds: 60
+`))
+	if err != nil {
+		t.Fatalf("ParseFixture: %v", err)
+	}
+	if issue := fixture.Issues[0]; issue.Key != "PROJ-1" || issue.Worklogs[0].Started != "2025-09-08T09:00:00.000+0100" {
+		t.Errorf("expected the key upper-cased and the start in the Jira format, got %+v", issue)
+	}
+
+	for _, data := range []string{
+		"issues: []",
+		"user: {name: jdoe}\nissues: [{key: PROJ}]",
+		"user: {name: jdoe}\nissues: [{key: PROJ-1}, {key: proj-1}]",
+		"user: {name: jdoe}\nissues: [{key: PROJ-1, worklogs: [{started: monday}]}]",
+		"user: {name: jdoe}\nfailures: [{path: /myself, status: 200}]",
+	} {
+		if _, err := mockjira.ParseFixture([]byte(data)); err == nil {
+			t.Errorf("ParseFixture(%q) succeeded, want an error", data)
+		}
+	}
+}
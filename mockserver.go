@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/liam-witterick/jira-worklogger/mockjira"
+)
+
+// runMockServer serves an in-memory fake of the Jira REST API until interrupted
+func runMockServer(args []string) int {
+	addr := "127.0.0.1:8080"
+	fixturePath := ""
+	failures := []mockjira.Failure{}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--addr" && i+1 < len(args):
+			addr = args[i+1]
+			i++
+		case args[i] == "--fixture" && i+1 < len(args):
+			fixturePath = args[i+1]
+			i++
+		case args[i] == "--fail" && i+1 < len(args):
+			failure, err := mockjira.ParseFailure(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+				return ExitConfig
+			}
+			failures = append(failures, failure)
+			i++
+		default:
+			fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger mock-server [--addr HOST:PORT] [--fixture FILE] [--fail METHOD:PATH:STATUS[:COUNT]]...")
+			return ExitConfig
+		}
+	}
+
+	fixture := mockjira.DefaultFixture()
+	if fixturePath != "" {
+		var err error
+		if fixture, err = mockjira.LoadFixture(fixturePath); err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			return ExitConfig
+		}
+	}
+	fixture.Failures = append(fixture.Failures, failures...)
+
+	logger := NewLogger("info")
+	server := mockjira.NewServer(fixture)
+	server.Logger = logger
+
+	logger.Info("Mock Jira listening on http://%s with %d issues", addr, len(fixture.Issues))
+	logger.Info("Set jira_base_url to http://%s, api_version 3 serves the Cloud API and 2 the Server API", addr)
+	if err := http.ListenAndServe(addr, server); err != nil {
+		logger.Error("Mock server stopped: %v", err)
+		return 1
+	}
+	return 0
+}
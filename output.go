@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of reports
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// ParseOutputFormat validates an --output value, defaulting to table
+func ParseOutputFormat(value string) (string, error) {
+	switch format := strings.ToLower(value); format {
+	case "":
+		return OutputTable, nil
+	case OutputTable, OutputJSON, OutputYAML:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid --output %s, must be json, yaml or table", value)
+}
+
+// WriteOutput writes v to stdout as a JSON or YAML document
+func WriteOutput(format string, v interface{}) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("output format %s is not machine-readable", format)
+}
+
+// RunReport is the machine-readable report of a run that posts worklogs
+type RunReport struct {
+	Status     string            `json:"status" yaml:"status"` // ok, partial, failed or nothing
+	ExitCode   int               `json:"exit_code" yaml:"exit_code"`
+	Error      string            `json:"error,omitempty" yaml:"error,omitempty"` // Why the run stopped before posting, e.g. an invalid config
+	Submission string            `json:"submission,omitempty" yaml:"submission,omitempty"`
+	Date       string            `json:"date,omitempty" yaml:"date,omitempty"` // Date of entries without a date header
+	Aliases    map[string]string `json:"aliases" yaml:"aliases"`               // Aliases used, resolved to issue keys
+	Posted     []ReportWorklog   `json:"posted" yaml:"posted"`
+	Failed     []ReportFailure   `json:"failed" yaml:"failed"`
+	Invalid    []ReportProblem   `json:"invalid_issues" yaml:"invalid_issues"`
+	RolledBack []ReportWorklog   `json:"rolled_back,omitempty" yaml:"rolled_back,omitempty"`
+	Orphans    []ReportWorklog   `json:"orphans,omitempty" yaml:"orphans,omitempty"`
+	Totals     ReportTotals      `json:"totals" yaml:"totals"`
+}
+
+// ReportWorklog is a posted worklog
+type ReportWorklog struct {
+	Date      string `json:"date" yaml:"date"`
+	Issue     string `json:"issue" yaml:"issue"`
+	Alias     string `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Seconds   int    `json:"seconds" yaml:"seconds"`
+	Started   string `json:"started" yaml:"started"`
+	WorklogID string `json:"worklog_id,omitempty" yaml:"worklog_id,omitempty"`
+	Recurring string `json:"recurring,omitempty" yaml:"recurring,omitempty"` // Rule that added the entry
+}
+
+// ReportFailure is an entry that could not be posted
+type ReportFailure struct {
+	Date       string `json:"date" yaml:"date"`
+	Issue      string `json:"issue" yaml:"issue"`
+	Alias      string `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Seconds    int    `json:"seconds" yaml:"seconds"`
+	StatusCode int    `json:"status_code" yaml:"status_code"` // 0 when Jira could not be reached
+	Kind       string `json:"kind" yaml:"kind"`
+	Retryable  bool   `json:"retryable" yaml:"retryable"`
+	Error      string `json:"error" yaml:"error"`
+}
+
+// ReportProblem is an issue that can't be logged to
+type ReportProblem struct {
+	Issue       string   `json:"issue" yaml:"issue"`
+	Reason      string   `json:"reason" yaml:"reason"`
+	Suggestions []string `json:"suggestions,omitempty" yaml:"suggestions,omitempty"`
+}
+
+// ReportTotals sums up a run
+type ReportTotals struct {
+	Posted        int            `json:"posted" yaml:"posted"`
+	Failed        int            `json:"failed" yaml:"failed"`
+	PostedSeconds int            `json:"posted_seconds" yaml:"posted_seconds"`
+	FailedSeconds int            `json:"failed_seconds" yaml:"failed_seconds"`
+	PerDay        map[string]int `json:"per_day" yaml:"per_day"` // Posted seconds per date
+}
+
+// NewRunReport returns an empty report
+func NewRunReport() *RunReport {
+	return &RunReport{
+		Aliases: map[string]string{},
+		Posted:  []ReportWorklog{},
+		Failed:  []ReportFailure{},
+		Invalid: []ReportProblem{},
+		Totals:  ReportTotals{PerDay: map[string]int{}},
+	}
+}
+
+// AddEntries records the aliases the entries were resolved from
+func (r *RunReport) AddEntries(entries []TimeEntry) {
+	for _, entry := range entries {
+		if entry.Alias != "" {
+			r.Aliases[entry.Alias] = entry.Issue
+		}
+	}
+}
+
+// AddPosted records a posted worklog
+func (r *RunReport) AddPosted(entry TimeEntry, result WorklogResult) {
+	r.Posted = append(r.Posted, reportWorklog(entry, result))
+	r.Totals.Posted++
+	r.Totals.PostedSeconds += result.Seconds
+	r.Totals.PerDay[result.Date] += result.Seconds
+}
+
+// AddFailed records an entry that could not be posted
+func (r *RunReport) AddFailed(failed FailedEntry) {
+	r.Failed = append(r.Failed, ReportFailure{
+		Date:       failed.Entry.Date,
+		Issue:      failed.Entry.Issue,
+		Alias:      failed.Entry.Alias,
+		Seconds:    failed.Entry.Seconds,
+		StatusCode: failed.Code,
+		Kind:       failed.Kind,
+		Retryable:  failed.Retryable,
+		Error:      failed.Reason,
+	})
+	r.Totals.Failed++
+	r.Totals.FailedSeconds += failed.Entry.Seconds
+}
+
+// MarkRolledBack moves the posted worklogs to the rolled back ones, except the orphans left in Jira
+func (r *RunReport) MarkRolledBack(orphans []WorklogResult) {
+	orphanIDs := map[string]bool{}
+	for _, orphan := range orphans {
+		orphanIDs[orphan.ID] = true
+	}
+	for _, posted := range r.Posted {
+		if orphanIDs[posted.WorklogID] {
+			r.Orphans = append(r.Orphans, posted)
+		} else {
+			r.RolledBack = append(r.RolledBack, posted)
+			r.Totals.Posted--
+			r.Totals.PostedSeconds -= posted.Seconds
+			r.Totals.PerDay[posted.Date] -= posted.Seconds
+			if r.Totals.PerDay[posted.Date] == 0 {
+				delete(r.Totals.PerDay, posted.Date)
+			}
+		}
+	}
+	r.Posted = append([]ReportWorklog{}, r.Orphans...)
+}
+
+// Finish sets the status from the exit code
+func (r *RunReport) Finish(exitCode int) {
+	r.ExitCode = exitCode
+	switch {
+	case exitCode == ExitPartial:
+		r.Status = "partial"
+	case exitCode != ExitOK:
+		r.Status = "failed"
+	case r.Totals.Posted == 0:
+		r.Status = "nothing"
+	default:
+		r.Status = "ok"
+	}
+}
+
+// reportWorklog converts a posted worklog for the report
+func reportWorklog(entry TimeEntry, result WorklogResult) ReportWorklog {
+	return ReportWorklog{
+		Date:      result.Date,
+		Issue:     result.Issue,
+		Alias:     entry.Alias,
+		Seconds:   result.Seconds,
+		Started:   result.Started,
+		WorklogID: result.ID,
+		Recurring: entry.Recurring,
+	}
+}
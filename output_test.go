@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// captureStdout returns what fn writes to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdout")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = file
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	file.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for value, want := range map[string]string{"": OutputTable, "table": OutputTable, "JSON": OutputJSON, "yaml": OutputYAML} {
+		if got, err := ParseOutputFormat(value); err != nil || got != want {
+			t.Errorf("ParseOutputFormat(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseOutputFormat("xml"); err == nil {
+		t.Error("expected an invalid format error")
+	}
+}
+
+func TestWriteOutputRunReport(t *testing.T) {
+	entry := TimeEntry{Date: "2025-09-08", Issue: "PROJ-123", Alias: "meetings", Seconds: 3600}
+	failedEntry := TimeEntry{Date: "2025-09-09", Issue: "OPS-7", Seconds: 1800}
+
+	report := NewRunReport()
+	report.Submission = "20250909-170000-abcd"
+	report.AddEntries([]TimeEntry{entry, failedEntry})
+	report.AddPosted(entry, WorklogResult{Date: "2025-09-08", Issue: "PROJ-123", Seconds: 3600, Started: "2025-09-08T17:00:00.000+0100", ID: "10001", Success: true})
+	report.AddFailed(NewFailedEntry(failedEntry, WorklogResult{Code: 503, Body: "unavailable"}))
+	report.Finish(ExitPartial)
+
+	// The keys scripts rely on, with the values of this run
+	want := map[string]interface{}{
+		"status":     "partial",
+		"exit_code":  float64(ExitPartial),
+		"submission": "20250909-170000-abcd",
+		"aliases":    map[string]interface{}{"meetings": "PROJ-123"},
+		"posted": []interface{}{map[string]interface{}{
+			"date": "2025-09-08", "issue": "PROJ-123", "alias": "meetings", "seconds": float64(3600),
+			"started": "2025-09-08T17:00:00.000+0100", "worklog_id": "10001",
+		}},
+		"failed": []interface{}{map[string]interface{}{
+			"date": "2025-09-09", "issue": "OPS-7", "seconds": float64(1800), "status_code": float64(503),
+			"kind": "server", "retryable": true, "error": "unavailable",
+		}},
+		"invalid_issues": []interface{}{},
+		"totals": map[string]interface{}{
+			"posted": float64(1), "failed": float64(1), "posted_seconds": float64(3600), "failed_seconds": float64(1800),
+			"per_day": map[string]interface{}{"2025-09-08": float64(3600)},
+		},
+	}
+
+	var decoded map[string]interface{}
+	output := captureStdout(t, func() {
+		if err := WriteOutput(OutputJSON, report); err != nil {
+			t.Fatalf("WriteOutput: %v", err)
+		}
+	})
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("expected one JSON document, got %q: %v", output, err)
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("JSON report\n got %v\nwant %v", decoded, want)
+	}
+
+	// YAML has the same keys
+	output = captureStdout(t, func() {
+		if err := WriteOutput(OutputYAML, report); err != nil {
+			t.Fatalf("WriteOutput: %v", err)
+		}
+	})
+	var yamlDecoded map[string]interface{}
+	if err := yaml.Unmarshal([]byte(output), &yamlDecoded); err != nil {
+		t.Fatalf("expected one YAML document, got %q: %v", output, err)
+	}
+	for key := range want {
+		if _, ok := yamlDecoded[key]; !ok {
+			t.Errorf("YAML report is missing %s:\n%s", key, output)
+		}
+	}
+
+	if err := WriteOutput(OutputTable, report); err == nil {
+		t.Error("expected an error for the table format")
+	}
+}
+
+func TestRunReportStatus(t *testing.T) {
+	entry := TimeEntry{Date: "2025-09-08", Issue: "PROJ-123", Seconds: 3600}
+	posted := func() *RunReport {
+		report := NewRunReport()
+		report.AddPosted(entry, WorklogResult{Date: "2025-09-08", Issue: "PROJ-123", Seconds: 3600, ID: "10001"})
+		report.AddPosted(entry, WorklogResult{Date: "2025-09-08", Issue: "PROJ-123", Seconds: 3600, ID: "10002"})
+		return report
+	}
+
+	tests := []struct {
+		name     string
+		report   *RunReport
+		exitCode int
+		want     string
+	}{
+		{"nothing", NewRunReport(), ExitOK, "nothing"},
+		{"ok", posted(), ExitOK, "ok"},
+		{"partial", posted(), ExitPartial, "partial"},
+		{"failed", NewRunReport(), ExitAuth, "failed"},
+	}
+	for _, tt := range tests {
+		tt.report.Finish(tt.exitCode)
+		if tt.report.Status != tt.want || tt.report.ExitCode != tt.exitCode {
+			t.Errorf("%s: status %q, exit code %d", tt.name, tt.report.Status, tt.report.ExitCode)
+		}
+	}
+
+	// Rolled back worklogs leave the totals, orphans stay posted
+	report := posted()
+	report.MarkRolledBack([]WorklogResult{{ID: "10002"}})
+	if len(report.RolledBack) != 1 || report.RolledBack[0].WorklogID != "10001" || len(report.Orphans) != 1 ||
+		len(report.Posted) != 1 || report.Posted[0].WorklogID != "10002" {
+		t.Errorf("unexpected rolled back report %+v", report)
+	}
+	if report.Totals.Posted != 1 || report.Totals.PostedSeconds != 3600 || report.Totals.PerDay["2025-09-08"] != 3600 {
+		t.Errorf("unexpected totals %+v", report.Totals)
+	}
+}
+
+func TestWriteOutputSetupCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "list",
+			value: ListResult{Aliases: map[string]string{}, Issues: []ListIssue{{Key: "PROJ-123", Summary: "Team meetings", Epic: "PROJ-100"}}},
+			want:  `{"aliases":{},"issues":[{"key":"PROJ-123","summary":"Team meetings","epic":"PROJ-100"}]}`,
+		},
+		{
+			name:  "doctor",
+			value: DoctorResult{OK: false, Checks: []DoctorCheck{{Name: "jira", OK: false, Detail: "HTTP 401: Unauthorized"}}},
+			want:  `{"ok":false,"checks":[{"name":"jira","ok":false,"detail":"HTTP 401: Unauthorized"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		output := captureStdout(t, func() {
+			if err := WriteOutput(OutputJSON, tt.value); err != nil {
+				t.Fatalf("WriteOutput: %v", err)
+			}
+		})
+		var got, want interface{}
+		if err := json.Unmarshal([]byte(output), &got); err != nil {
+			t.Fatalf("%s: expected one JSON document, got %q", tt.name, output)
+		}
+		json.Unmarshal([]byte(tt.want), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s JSON\n got %s\nwant %s", tt.name, output, tt.want)
+		}
+	}
+}
+
+func TestMainSetupFailureReport(t *testing.T) {
+	// The subprocess runs main with the arguments of WORKLOG_TEST_ARGS
+	if args := os.Getenv("WORKLOG_TEST_ARGS"); args != "" {
+		os.Args = append([]string{"jira-worklogger"}, strings.Fields(args)...)
+		main()
+		return
+	}
+
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.yaml")
+	placeholder := filepath.Join(dir, "placeholder.yaml")
+	for path, config := range map[string]string{
+		invalid:     "timer:\n  rounding_mode: sideways\n",
+		placeholder: "jira_api_token: YOUR_API_TOKEN\n",
+	} {
+		if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		token  string
+		format string
+		want   string // In the error of the report
+	}{
+		{name: "invalid config", config: invalid, token: "secret", format: "json", want: "rounding_mode"},
+		{name: "missing token", config: invalid, format: "json", want: "JIRA_API_TOKEN"},
+		{name: "placeholder token", config: placeholder, format: "json", want: "placeholder"},
+		{name: "yaml", config: invalid, token: "secret", format: "yaml", want: "rounding_mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestMainSetupFailureReport$")
+			cmd.Env = append(os.Environ(),
+				"WORKLOG_TEST_ARGS=--entries PROJ-1=1h --output "+tt.format,
+				"WORKLOG_CONFIG="+tt.config,
+				"WORKLOG_STATE_DIR="+dir,
+				"JIRA_BASE_URL=http://127.0.0.1:1",
+				"JIRA_EMAIL=jane.doe@example.com",
+				"JIRA_API_TOKEN="+tt.token,
+				"LOG_FILE=",
+			)
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout, cmd.Stderr = &stdout, &stderr
+			err := cmd.Run()
+			if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != ExitConfig {
+				t.Fatalf("expected exit code %d, got %v\n%s", ExitConfig, err, stderr.String())
+			}
+
+			var report RunReport
+			if tt.format == "json" {
+				decoder := json.NewDecoder(&stdout)
+				if err := decoder.Decode(&report); err != nil {
+					t.Fatalf("stdout is not a JSON document: %v", err)
+				}
+				if decoder.More() {
+					t.Errorf("expected a single JSON document on stdout")
+				}
+			} else if err := yaml.Unmarshal(stdout.Bytes(), &report); err != nil {
+				t.Fatalf("stdout is not a YAML document: %v", err)
+			}
+			if report.Status != "failed" || report.ExitCode != ExitConfig || !strings.Contains(report.Error, tt.want) {
+				t.Errorf("unexpected report %+v", report)
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("expected the error on stderr, got %q", stderr.String())
+			}
+		})
+	}
+}
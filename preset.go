@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// presetRemainder is the duration of a preset line that fills the rest of the day's target
+const presetRemainder = "*"
+
+// PresetLine is a line of a preset of the config, parsed when the settings are loaded
+type PresetLine struct {
+	Alias     string // Issue key or category alias
+	Seconds   int
+	Remainder bool // Fills what is left of the day's target
+	Comment   string
+}
+
+// NewPresets parses the presets section of the settings. Each line is alias=duration with an
+// optional "# comment"; a duration of * takes the rest of the day's target.
+func NewPresets(settings *Settings) (map[string][]PresetLine, error) {
+	presets := map[string][]PresetLine{}
+	for name, lines := range settings.Presets {
+		preset := []PresetLine{}
+		remainders := 0
+		for _, line := range lines {
+			text, comment, _ := strings.Cut(line, "#")
+			var alias, duration string
+			if parts := strings.SplitN(text, "=", 2); len(parts) == 2 {
+				alias, duration = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			} else if fields := strings.Fields(text); len(fields) == 2 {
+				alias, duration = fields[0], fields[1]
+			}
+			if alias == "" || duration == "" {
+				return nil, fmt.Errorf("invalid presets.%s line %q, expected alias=duration", name, line)
+			}
+
+			presetLine := PresetLine{Alias: alias, Comment: strings.TrimSpace(comment)}
+			if duration == presetRemainder {
+				presetLine.Remainder = true
+				remainders++
+			} else {
+				seconds, err := ToTimeSpentSeconds(duration)
+				if err != nil || seconds <= 0 {
+					return nil, fmt.Errorf("invalid presets.%s line %q: invalid duration %q", name, line, duration)
+				}
+				presetLine.Seconds = seconds
+			}
+			preset = append(preset, presetLine)
+		}
+		if remainders > 1 {
+			return nil, fmt.Errorf("invalid presets.%s: only one line can take the remainder (*)", name)
+		}
+		presets[strings.ToLower(name)] = preset
+	}
+	return presets, nil
+}
+
+// PresetEntries returns the entries of a preset for a date
+func PresetEntries(settings *Settings, logger *Logger, name, dateStr string) ([]TimeEntry, error) {
+	preset, ok := settings.presets[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(settings.presets))
+		for presetName := range settings.presets {
+			names = append(names, presetName)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown preset %s, no presets are configured", name)
+		}
+		return nil, fmt.Errorf("unknown preset %s, expected one of: %s", name, strings.Join(names, ", "))
+	}
+
+	entries := []TimeEntry{}
+	for _, line := range preset {
+		entry := TimeEntry{
+			Date:      dateStr,
+			Issue:     ResolveAlias(line.Alias, settings.CategoryAliases, logger),
+			Seconds:   line.Seconds,
+			Comment:   line.Comment,
+			Remainder: line.Remainder,
+		}
+		if entry.Issue != line.Alias {
+			entry.Alias = line.Alias
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ResolveRemainders sets the duration of remainder entries to what is left of their day's
+// target after the other entries and the time already logged in Jira. Remainder entries with
+// nothing left are dropped.
+func ResolveRemainders(settings *Settings, logger *Logger, entries []TimeEntry) []TimeEntry {
+	remainders := map[string]int{} // Remainder entries by date
+	dates := []string{}
+	for _, entry := range entries {
+		if entry.Remainder {
+			if remainders[entry.Date] == 0 {
+				dates = append(dates, entry.Date)
+			}
+			remainders[entry.Date]++
+		}
+	}
+	if len(dates) == 0 {
+		return entries
+	}
+	sort.Strings(dates)
+
+	used := map[string]int{}
+	existing, err := GetMyWorklogs(settings, logger, dates[0], dates[len(dates)-1])
+	if err != nil {
+		logger.Warn("Failed to load existing worklogs, remainders ignore the time already logged: %v", err)
+	}
+	for _, worklog := range existing {
+		used[worklog.Date] += worklog.Seconds
+	}
+	for _, entry := range entries {
+		if !entry.Remainder {
+			used[entry.Date] += entry.Seconds
+		}
+	}
+
+	calendar := settings.WorkCalendar()
+	left := map[string]int{}
+	for _, dateStr := range dates {
+		date, err := ParseDate(dateStr)
+		if err != nil {
+			continue
+		}
+		left[dateStr] = calendar.TargetSeconds(date) - used[dateStr]
+	}
+
+	resolved := []TimeEntry{}
+	for _, entry := range entries {
+		if !entry.Remainder {
+			resolved = append(resolved, entry)
+			continue
+		}
+		// Split the remainder evenly in whole minutes, the last entry of the day taking the rest
+		share := left[entry.Date] / remainders[entry.Date] / 60 * 60
+		if remainders[entry.Date] == 1 {
+			share = left[entry.Date]
+		}
+		left[entry.Date] -= share
+		remainders[entry.Date]--
+		if share <= 0 {
+			logger.Warn("Nothing left of the target on %s for %s, leaving it out", entry.Date, entry.Issue)
+			continue
+		}
+		logger.Info("Filling the rest of %s with %s: %s", entry.Date, entry.Issue, FormatSeconds(share))
+		entry.Seconds = share
+		entry.Remainder = false
+		resolved = append(resolved, entry)
+	}
+	return resolved
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPresetEntries(t *testing.T) {
+	settings := newTestSettings(t, `
+defaults:
+  daily_target: 7.5h
+  category_aliases:
+    meetings: PROJ-123
+presets:
+  Monday:
+    - "meetings=30m # Sprint planning"
+    - "PROJ-124 * # Reviews"
+  friday:
+    - "OPS-7=1.5h"
+`)
+	logger := NewLogger("error")
+
+	entries, err := PresetEntries(settings, logger, "monday", "2025-09-08")
+	if err != nil {
+		t.Fatalf("PresetEntries: %v", err)
+	}
+	want := []TimeEntry{
+		{Date: "2025-09-08", Issue: "PROJ-123", Alias: "meetings", Seconds: 1800, Comment: "Sprint planning"},
+		{Date: "2025-09-08", Issue: "PROJ-124", Comment: "Reviews", Remainder: true},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("PresetEntries\n got %+v\nwant %+v", entries, want)
+	}
+
+	// 7.5h target, less the 30m of meetings and the 1h of PROJ-124 already in Jira
+	entries = ResolveRemainders(settings, logger, entries)
+	want[1].Seconds, want[1].Remainder = 6*3600, false
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("resolved preset\n got %+v\nwant %+v", entries, want)
+	}
+
+	if _, err := PresetEntries(settings, logger, "tuesday", "2025-09-09"); err == nil || !strings.Contains(err.Error(), "friday, monday") {
+		t.Errorf("expected an unknown preset error listing the presets, got %v", err)
+	}
+}
+
+func TestPresetRemainderAndCommentsSurviveEditing(t *testing.T) {
+	settings := newTestSettings(t, `
+defaults:
+  category_aliases:
+    meetings: PROJ-123
+presets:
+  monday:
+    - "meetings=30m # Sprint planning"
+    - "PROJ-124=* # Reviews"
+`)
+	logger := NewLogger("error")
+
+	entries, err := PresetEntries(settings, logger, "Monday", "2025-09-08")
+	if err != nil {
+		t.Fatalf("PresetEntries: %v", err)
+	}
+	// 7.5h target, less the 30m of meetings and the 1h of PROJ-124 already in Jira
+	entries = ResolveRemainders(settings, logger, entries)
+	want := []TimeEntry{
+		{Date: "2025-09-08", Issue: "PROJ-123", Alias: "meetings", Seconds: 1800, Comment: "Sprint planning"},
+		{Date: "2025-09-08", Issue: "PROJ-124", Seconds: 6 * 3600, Comment: "Reviews"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("resolved preset\n got %+v\nwant %+v", entries, want)
+	}
+
+	text := FormatEntriesText(GroupEntriesByDate(entries))
+	edited, err := ParseTimeEntries(text, "2025-09-08", settings.CategoryAliases, logger)
+	if err != nil {
+		t.Fatalf("ParseTimeEntries(%q): %v", text, err)
+	}
+	if !reflect.DeepEqual(edited, want) {
+		t.Errorf("edited preset %q\n got %+v\nwant %+v", text, edited, want)
+	}
+}
+
+func TestNewPresetsErrors(t *testing.T) {
+	for name, lines := range map[string][]string{
+		"no duration":      {"meetings"},
+		"invalid duration": {"meetings=often"},
+		"zero duration":    {"meetings=0"},
+		"two remainders":   {"meetings=*", "PROJ-124=*"},
+		"empty alias":      {"=1h"},
+		"too many fields":  {"meetings 1h 30m"},
+	} {
+		settings := &Settings{Presets: map[string][]string{"day": lines}}
+		if _, err := NewPresets(settings); err == nil {
+			t.Errorf("%s: expected an error for %q", name, lines)
+		}
+	}
+}
+
+func TestResolveRemainders(t *testing.T) {
+	settings := newTestSettings(t, `
+calendar:
+  targets: {fri: "4h"}
+`)
+	remainder := func(date, issue string) TimeEntry {
+		return TimeEntry{Date: date, Issue: issue, Remainder: true}
+	}
+	entry := func(date, issue string, seconds int) TimeEntry {
+		return TimeEntry{Date: date, Issue: issue, Seconds: seconds}
+	}
+
+	tests := []struct {
+		name    string
+		entries []TimeEntry
+		want    []TimeEntry
+	}{
+		{
+			name:    "no remainders",
+			entries: []TimeEntry{entry("2025-09-09", "PROJ-123", 3600)},
+			want:    []TimeEntry{entry("2025-09-09", "PROJ-123", 3600)},
+		},
+		{
+			name:    "counts the time already in Jira",
+			entries: []TimeEntry{remainder("2025-09-08", "PROJ-123"), entry("2025-09-08", "OPS-7", 1800)},
+			want:    []TimeEntry{entry("2025-09-08", "PROJ-123", 6*3600), entry("2025-09-08", "OPS-7", 1800)},
+		},
+		{
+			name:    "split evenly in whole minutes",
+			entries: []TimeEntry{entry("2025-09-09", "OPS-7", 3660), remainder("2025-09-09", "PROJ-123"), remainder("2025-09-09", "PROJ-124")},
+			want:    []TimeEntry{entry("2025-09-09", "OPS-7", 3660), entry("2025-09-09", "PROJ-123", 194*60), entry("2025-09-09", "PROJ-124", 195*60)},
+		},
+		{
+			name:    "per day targets",
+			entries: []TimeEntry{remainder("2025-09-11", "PROJ-123"), remainder("2025-09-12", "PROJ-123")},
+			want:    []TimeEntry{entry("2025-09-11", "PROJ-123", 7*3600+1800), entry("2025-09-12", "PROJ-123", 4*3600)},
+		},
+		{
+			name:    "nothing left",
+			entries: []TimeEntry{entry("2025-09-09", "OPS-7", 8*3600), remainder("2025-09-09", "PROJ-123")},
+			want:    []TimeEntry{entry("2025-09-09", "OPS-7", 8*3600)},
+		},
+		{
+			name:    "day off",
+			entries: []TimeEntry{remainder("2025-09-13", "PROJ-123")},
+			want:    []TimeEntry{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveRemainders(settings, NewLogger("error"), tt.entries)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveRemainders\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
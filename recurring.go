@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecurringRule is a recurring entry of the config, parsed when the settings are loaded
+type RecurringRule struct {
+	name     string
+	alias    string // Issue key or category alias, as configured
+	seconds  int
+	days     map[time.Weekday]bool // Empty for every working day
+	interval int                   // Weeks between occurrences
+	from     time.Time             // Anchors the interval, zero when not set
+	to       time.Time             // Zero when the rule does not end
+	start    string
+	comment  string
+}
+
+// NewRecurringRules parses the recurring section of the settings
+func NewRecurringRules(settings *Settings) ([]RecurringRule, error) {
+	rules := []RecurringRule{}
+	for i, config := range settings.Recurring {
+		name := config.Name
+		if name == "" {
+			name = config.Alias
+		}
+		if config.Alias == "" {
+			return nil, fmt.Errorf("invalid recurring rule %d: missing alias", i+1)
+		}
+		rule := RecurringRule{
+			name:     name,
+			alias:    config.Alias,
+			days:     map[time.Weekday]bool{},
+			interval: config.Interval,
+			start:    config.Start,
+			comment:  config.Comment,
+		}
+
+		var err error
+		if rule.seconds, err = ToTimeSpentSeconds(config.Duration); err != nil || rule.seconds <= 0 {
+			return nil, fmt.Errorf("invalid recurring rule %s: invalid duration %q", name, config.Duration)
+		}
+		for _, day := range config.Days {
+			weekday, ok := weekdayNames[strings.ToLower(day)]
+			if !ok {
+				return nil, fmt.Errorf("invalid recurring rule %s: invalid day %q, expected e.g. mon", name, day)
+			}
+			rule.days[weekday] = true
+		}
+		if rule.interval == 0 {
+			rule.interval = 1
+		}
+		if rule.interval < 0 {
+			return nil, fmt.Errorf("invalid recurring rule %s: interval must be a number of weeks", name)
+		}
+		if config.StartDate != "" {
+			if rule.from, err = time.Parse("2006-01-02", config.StartDate); err != nil {
+				return nil, fmt.Errorf("invalid recurring rule %s: invalid start_date %q, expected YYYY-MM-DD", name, config.StartDate)
+			}
+		} else if rule.interval > 1 {
+			return nil, fmt.Errorf("invalid recurring rule %s: an interval of %d weeks needs a start_date", name, rule.interval)
+		}
+		if config.EndDate != "" {
+			if rule.to, err = time.Parse("2006-01-02", config.EndDate); err != nil {
+				return nil, fmt.Errorf("invalid recurring rule %s: invalid end_date %q, expected YYYY-MM-DD", name, config.EndDate)
+			}
+		}
+		if rule.start != "" {
+			if _, _, err := ParseClock(rule.start); err != nil {
+				return nil, fmt.Errorf("invalid recurring rule %s: %v", name, err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// appliesOn reports whether the rule recurs on a date. Rules only recur on working days.
+func (r RecurringRule) appliesOn(date time.Time, calendar *Calendar) bool {
+	if calendar.TargetSeconds(date) == 0 {
+		return false
+	}
+	if len(r.days) > 0 && !r.days[date.Weekday()] {
+		return false
+	}
+	if (!r.from.IsZero() && date.Before(r.from)) || (!r.to.IsZero() && date.After(r.to)) {
+		return false
+	}
+	if r.interval > 1 {
+		weeks := int(weekMonday(date).Sub(weekMonday(r.from)).Hours()/24) / 7
+		if weeks%r.interval != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// weekMonday returns the Monday of the week of a date
+func weekMonday(date time.Time) time.Time {
+	return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+}
+
+// ApplyRecurring adds the recurring entries of the config to each day that has entries. A rule
+// is skipped on a day that already has an entry of the same issue and duration, typed or in Jira.
+func ApplyRecurring(settings *Settings, logger *Logger, entries []TimeEntry) []TimeEntry {
+	if len(settings.recurring) == 0 || len(entries) == 0 {
+		return entries
+	}
+
+	dates := []string{}
+	present := map[string]bool{} // Date, issue and duration of the entries of each day
+	for _, entry := range entries {
+		if !containsString(dates, entry.Date) {
+			dates = append(dates, entry.Date)
+		}
+		present[recurringKey(entry.Date, entry.Issue, entry.Seconds)] = true
+	}
+	sort.Strings(dates)
+
+	calendar := settings.WorkCalendar()
+	added := []TimeEntry{}
+	for _, dateStr := range dates {
+		date, err := ParseDate(dateStr)
+		if err != nil {
+			continue
+		}
+		for _, rule := range settings.recurring {
+			if rule.appliesOn(date, calendar) {
+				added = append(added, rule.entry(dateStr, settings, NewLogger("error")))
+			}
+		}
+	}
+	if len(added) == 0 {
+		return entries
+	}
+
+	// Don't log a recurring entry twice when the tool runs again on the same day
+	existing, err := GetMyWorklogs(settings, logger, dates[0], dates[len(dates)-1])
+	if err != nil {
+		logger.Warn("Failed to load existing worklogs, recurring entries may be logged twice: %v", err)
+	}
+	for _, worklog := range existing {
+		present[recurringKey(worklog.Date, worklog.Issue, worklog.Seconds)] = true
+	}
+
+	for _, entry := range added {
+		if present[recurringKey(entry.Date, entry.Issue, entry.Seconds)] {
+			logger.Info("Skipping recurring %s on %s, %s already has %s that day", entry.Recurring, entry.Date, entry.Issue, FormatSeconds(entry.Seconds))
+			continue
+		}
+		logger.Info("Adding recurring %s on %s: %s", entry.Recurring, entry.Date, FormatSeconds(entry.Seconds))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// MarkRecurring marks the entries with the issue and duration of a recurring rule of their day,
+// e.g. after the entries were edited as text, restoring the start time and comment of the rule
+func MarkRecurring(settings *Settings, entries []TimeEntry) {
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Recurring != "" {
+			continue
+		}
+		if rule, ok := recurringRuleFor(settings, entry.Date, entry.Issue, entry.Seconds); ok {
+			entry.Recurring = rule.name
+			if entry.Start == "" {
+				entry.Start = rule.start
+			}
+			if entry.Comment == "" {
+				entry.Comment = rule.comment
+			}
+		}
+	}
+}
+
+// recurringRuleFor returns the recurring rule of a date with the issue and duration of an entry
+func recurringRuleFor(settings *Settings, dateStr, issue string, seconds int) (RecurringRule, bool) {
+	date, err := ParseDate(dateStr)
+	if err != nil {
+		return RecurringRule{}, false
+	}
+	calendar := settings.WorkCalendar()
+	for _, rule := range settings.recurring {
+		if !rule.appliesOn(date, calendar) || rule.seconds != seconds {
+			continue
+		}
+		if ruleEntry := rule.entry(dateStr, settings, NewLogger("error")); strings.EqualFold(ruleEntry.Issue, issue) {
+			return rule, true
+		}
+	}
+	return RecurringRule{}, false
+}
+
+// recurringKey identifies an entry of an issue and duration on a date
+func recurringKey(dateStr, issue string, seconds int) string {
+	return fmt.Sprintf("%s %s %d", dateStr, strings.ToUpper(issue), seconds)
+}
+
+// entry returns the time entry of the rule on a date
+func (r RecurringRule) entry(dateStr string, settings *Settings, logger *Logger) TimeEntry {
+	entry := TimeEntry{
+		Date:      dateStr,
+		Issue:     ResolveAlias(r.alias, settings.CategoryAliases, logger),
+		Seconds:   r.seconds,
+		Start:     r.start,
+		Comment:   r.comment,
+		Recurring: r.name,
+	}
+	if entry.Issue != r.alias {
+		entry.Alias = r.alias
+	}
+	return entry
+}
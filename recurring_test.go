@@ -0,0 +1,146 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyRecurring(t *testing.T) {
+	settings := newTestSettings(t, `
+defaults:
+  category_aliases:
+    meetings: PROJ-123
+recurring:
+  - name: standup
+    alias: meetings
+    duration: 15m
+    start: "09:30"
+    comment: Daily standup
+  - name: review
+    alias: PROJ-124
+    duration: 1h
+    days: [mon]
+  - name: retro
+    alias: PROJ-123
+    duration: 1h
+    days: [fri]
+    interval: 2
+    start_date: 2025-09-05
+    end_date: 2025-09-30
+`)
+	standup := func(date string) TimeEntry {
+		return TimeEntry{Date: date, Issue: "PROJ-123", Alias: "meetings", Seconds: 900, Start: "09:30", Comment: "Daily standup", Recurring: "standup"}
+	}
+	retro := func(date string) TimeEntry {
+		return TimeEntry{Date: date, Issue: "PROJ-123", Seconds: 3600, Recurring: "retro"}
+	}
+	review := func(date string) TimeEntry {
+		return TimeEntry{Date: date, Issue: "PROJ-124", Seconds: 3600, Recurring: "review"}
+	}
+	entry := func(date, issue string, seconds int) TimeEntry {
+		return TimeEntry{Date: date, Issue: issue, Seconds: seconds}
+	}
+
+	tests := []struct {
+		name    string
+		entries []TimeEntry
+		want    []TimeEntry
+	}{
+		{
+			name:    "no entries",
+			entries: []TimeEntry{},
+			want:    []TimeEntry{},
+		},
+		{
+			name:    "every working day",
+			entries: []TimeEntry{entry("2025-09-09", "OPS-7", 7200)},
+			want:    []TimeEntry{entry("2025-09-09", "OPS-7", 7200), standup("2025-09-09")},
+		},
+		{
+			name:    "already logged in Jira",
+			entries: []TimeEntry{entry("2025-09-08", "OPS-7", 7200)},
+			want:    []TimeEntry{entry("2025-09-08", "OPS-7", 7200), standup("2025-09-08")},
+		},
+		{
+			name:    "already typed",
+			entries: []TimeEntry{entry("2025-09-16", "PROJ-123", 900), entry("2025-09-17", "PROJ-123", 1800)},
+			want:    []TimeEntry{entry("2025-09-16", "PROJ-123", 900), entry("2025-09-17", "PROJ-123", 1800), standup("2025-09-17")},
+		},
+		{
+			name:    "every other week",
+			entries: []TimeEntry{entry("2025-09-05", "OPS-7", 3600), entry("2025-09-12", "OPS-7", 3600), entry("2025-09-19", "OPS-7", 3600)},
+			want: []TimeEntry{
+				entry("2025-09-05", "OPS-7", 3600), entry("2025-09-12", "OPS-7", 3600), entry("2025-09-19", "OPS-7", 3600),
+				standup("2025-09-05"), retro("2025-09-05"), standup("2025-09-12"), standup("2025-09-19"), retro("2025-09-19"),
+			},
+		},
+		{
+			name:    "before the start and after the end",
+			entries: []TimeEntry{entry("2025-08-22", "OPS-7", 3600), entry("2025-10-03", "OPS-7", 3600)},
+			want:    []TimeEntry{entry("2025-08-22", "OPS-7", 3600), entry("2025-10-03", "OPS-7", 3600), standup("2025-08-22"), standup("2025-10-03")},
+		},
+		{
+			name:    "specific days",
+			entries: []TimeEntry{entry("2025-09-15", "OPS-7", 3600)},
+			want:    []TimeEntry{entry("2025-09-15", "OPS-7", 3600), standup("2025-09-15"), review("2025-09-15")},
+		},
+		{
+			name:    "days off",
+			entries: []TimeEntry{entry("2025-09-13", "OPS-7", 3600)},
+			want:    []TimeEntry{entry("2025-09-13", "OPS-7", 3600)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyRecurring(settings, NewLogger("error"), tt.entries)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyRecurring\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRecurringRulesErrors(t *testing.T) {
+	for name, config := range map[string]RecurringConfig{
+		"missing alias":    {Duration: "1h"},
+		"invalid duration": {Alias: "PROJ-123", Duration: "often"},
+		"invalid day":      {Alias: "PROJ-123", Duration: "1h", Days: []string{"someday"}},
+		"no start date":    {Alias: "PROJ-123", Duration: "1h", Interval: 2},
+		"invalid end date": {Alias: "PROJ-123", Duration: "1h", EndDate: "30/09/2025"},
+		"invalid start":    {Alias: "PROJ-123", Duration: "1h", Start: "noon"},
+	} {
+		settings := &Settings{Recurring: []RecurringConfig{config}}
+		if _, err := NewRecurringRules(settings); err == nil {
+			t.Errorf("%s: expected an error for %+v", name, config)
+		}
+	}
+}
+
+func TestMarkRecurring(t *testing.T) {
+	settings := newTestSettings(t, `
+recurring:
+  - name: standup
+    alias: PROJ-123
+    duration: 15m
+    start: "09:30"
+    comment: Daily standup
+    days: [mon, tue]
+`)
+	entries := []TimeEntry{
+		{Date: "2025-09-08", Issue: "proj-123", Seconds: 900},
+		{Date: "2025-09-09", Issue: "PROJ-123", Seconds: 900, Start: "10:00", Comment: "Moved"},
+		{Date: "2025-09-09", Issue: "PROJ-123", Seconds: 1800},
+		{Date: "2025-09-10", Issue: "PROJ-123", Seconds: 900},
+	}
+	MarkRecurring(settings, entries)
+	want := []TimeEntry{
+		{Date: "2025-09-08", Issue: "proj-123", Seconds: 900, Start: "09:30", Comment: "Daily standup", Recurring: "standup"},
+		{Date: "2025-09-09", Issue: "PROJ-123", Seconds: 900, Start: "10:00", Comment: "Moved", Recurring: "standup"},
+		{Date: "2025-09-09", Issue: "PROJ-123", Seconds: 1800},
+		{Date: "2025-09-10", Issue: "PROJ-123", Seconds: 900},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("MarkRecurring\n got %+v\nwant %+v", entries, want)
+	}
+}
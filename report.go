@@ -0,0 +1,387 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Report groupings
+var reportGroupings = []string{"day", "issue", "epic", "project", "alias"}
+
+// Report formats besides the machine-readable output formats
+const (
+	OutputCSV      = "csv"
+	OutputMarkdown = "markdown"
+)
+
+// Timesheet is the current user's time logged over a range of dates
+type Timesheet struct {
+	From          string           `json:"from" yaml:"from"`
+	To            string           `json:"to" yaml:"to"`
+	TotalSeconds  int              `json:"total_seconds" yaml:"total_seconds"`
+	TargetSeconds int              `json:"target_seconds" yaml:"target_seconds"` // Expected up to today
+	GapSeconds    int              `json:"gap_seconds" yaml:"gap_seconds"`
+	Days          []TimesheetDay   `json:"days" yaml:"days"`
+	Issues        []TimesheetGroup `json:"issues" yaml:"issues"`
+	Epics         []TimesheetGroup `json:"epics" yaml:"epics"`
+	Projects      []TimesheetGroup `json:"projects" yaml:"projects"`
+	Aliases       []TimesheetGroup `json:"aliases" yaml:"aliases"`
+}
+
+// TimesheetDay is the time logged on one day against the daily target
+type TimesheetDay struct {
+	Date          string `json:"date" yaml:"date"`
+	Weekday       string `json:"weekday" yaml:"weekday"`
+	Seconds       int    `json:"seconds" yaml:"seconds"`
+	TargetSeconds int    `json:"target_seconds" yaml:"target_seconds"`
+	GapSeconds    int    `json:"gap_seconds" yaml:"gap_seconds"`             // Missing time, only for days up to today
+	DayOff        string `json:"day_off,omitempty" yaml:"day_off,omitempty"` // weekend, holiday or pto
+}
+
+// TimesheetGroup is the time logged to an issue, epic, project or alias
+type TimesheetGroup struct {
+	Name    string `json:"name" yaml:"name"`
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Seconds int    `json:"seconds" yaml:"seconds"`
+}
+
+// Names of the groups of worklogs without an epic or an alias
+const (
+	noEpicGroup  = "(no epic)"
+	noAliasGroup = "(no alias)"
+)
+
+// BuildTimesheet aggregates worklogs over a range of dates. epics maps the worklogged issues
+// to their epic. Gaps are only counted up to the cutoff date, usually today.
+func BuildTimesheet(settings *Settings, dates DateRange, cutoffStr string, worklogs []ExistingWorklog, epics map[string]Epic) *Timesheet {
+	timesheet := &Timesheet{From: dates.From.Format("2006-01-02"), To: dates.To.Format("2006-01-02")}
+	calendar := settings.WorkCalendar()
+
+	perDay := map[string]int{}
+	for _, worklog := range worklogs {
+		perDay[worklog.Date] += worklog.Seconds
+		timesheet.TotalSeconds += worklog.Seconds
+	}
+	for _, dateStr := range dates.Days() {
+		date, _ := ParseDate(dateStr)
+		day := TimesheetDay{
+			Date:          dateStr,
+			Weekday:       date.Format("Mon"),
+			Seconds:       perDay[dateStr],
+			TargetSeconds: calendar.TargetSeconds(date),
+			DayOff:        calendar.DayOff(date),
+		}
+		if dateStr <= cutoffStr {
+			timesheet.TargetSeconds += day.TargetSeconds
+			if day.Seconds < day.TargetSeconds {
+				day.GapSeconds = day.TargetSeconds - day.Seconds
+				timesheet.GapSeconds += day.GapSeconds
+			}
+		}
+		timesheet.Days = append(timesheet.Days, day)
+	}
+
+	// Several aliases may point at the same issue
+	aliasesByIssue := map[string][]string{}
+	for alias, issue := range settings.CategoryAliases {
+		key := strings.ToUpper(issue)
+		aliasesByIssue[key] = append(aliasesByIssue[key], alias)
+	}
+	for key := range aliasesByIssue {
+		sort.Strings(aliasesByIssue[key])
+	}
+
+	byIssue, byEpic, byProject, byAlias := groupTotals{}, groupTotals{}, groupTotals{}, groupTotals{}
+	for _, worklog := range worklogs {
+		key := strings.ToUpper(worklog.Issue)
+		byIssue.add(key, worklog.Summary, worklog.Seconds)
+
+		if epic, ok := epics[key]; ok {
+			byEpic.add(epic.Key, epic.Summary, worklog.Seconds)
+		} else {
+			byEpic.add(noEpicGroup, "", worklog.Seconds)
+		}
+
+		project, _, _ := strings.Cut(key, "-")
+		byProject.add(project, "", worklog.Seconds)
+
+		if aliases := aliasesByIssue[key]; len(aliases) > 0 {
+			byAlias.add(strings.Join(aliases, ", "), key, worklog.Seconds)
+		} else {
+			byAlias.add(noAliasGroup, "", worklog.Seconds)
+		}
+	}
+	timesheet.Issues = byIssue.sorted()
+	timesheet.Epics = byEpic.sorted()
+	timesheet.Projects = byProject.sorted()
+	timesheet.Aliases = byAlias.sorted()
+
+	return timesheet
+}
+
+// groupTotals sums up seconds per group name
+type groupTotals map[string]*TimesheetGroup
+
+func (g groupTotals) add(name, summary string, seconds int) {
+	group, ok := g[name]
+	if !ok {
+		group = &TimesheetGroup{Name: name, Summary: summary}
+		g[name] = group
+	}
+	group.Seconds += seconds
+}
+
+// sorted returns the groups, most time first
+func (g groupTotals) sorted() []TimesheetGroup {
+	groups := []TimesheetGroup{}
+	for _, group := range g {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Seconds != groups[j].Seconds {
+			return groups[i].Seconds > groups[j].Seconds
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// Groups returns the groups of a grouping other than day
+func (t *Timesheet) Groups(by string) []TimesheetGroup {
+	switch by {
+	case "epic":
+		return t.Epics
+	case "project":
+		return t.Projects
+	case "alias":
+		return t.Aliases
+	}
+	return t.Issues
+}
+
+// runReport prints the time logged over a range of dates, aggregated by day, issue, epic,
+// project or alias
+func runReport(args []string) int {
+	spec := ""
+	by := "day"
+	outputFormat := OutputTable
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--by" && i+1 < len(args):
+			by = strings.ToLower(args[i+1])
+			if !containsString(reportGroupings, by) {
+				fmt.Fprintf(os.Stderr, "[error] Invalid --by %s, must be one of %s\n", args[i+1], strings.Join(reportGroupings, ", "))
+				return ExitConfig
+			}
+			i++
+		case args[i] == "--output" && i+1 < len(args):
+			format, err := parseReportFormat(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+				return ExitConfig
+			}
+			outputFormat = format
+			i++
+		case !strings.HasPrefix(args[i], "--") && spec == "":
+			spec = args[i]
+		default:
+			fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger report [RANGE] [--by day|issue|epic|project|alias] [--output table|csv|markdown|json|yaml]")
+			return ExitConfig
+		}
+	}
+
+	if spec == "" {
+		spec = "this-week"
+	}
+	todayStr := DefaultDateStr()
+	dates, err := ParseDateRange(spec, todayStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return ExitConfig
+	}
+
+	settings, logger := setup()
+	timesheet, err := FetchTimesheet(settings, logger, dates, todayStr)
+	if err != nil {
+		logger.Error("Failed to fetch worklogs: %v", err)
+		return ExitCodeFor(err)
+	}
+
+	switch outputFormat {
+	case OutputJSON, OutputYAML:
+		if err := WriteOutput(outputFormat, timesheet); err != nil {
+			logger.Error("Failed to write report: %v", err)
+			return ExitFailure
+		}
+	case OutputCSV:
+		if err := writeTimesheetCSV(timesheet, by); err != nil {
+			logger.Error("Failed to write CSV: %v", err)
+			return ExitFailure
+		}
+	case OutputMarkdown:
+		fmt.Print(renderTimesheet(timesheet, by, true))
+	default:
+		fmt.Print(renderTimesheet(timesheet, by, false))
+	}
+	return ExitOK
+}
+
+// FetchTimesheet fetches the current user's worklogs over a range of dates and aggregates them
+func FetchTimesheet(settings *Settings, logger *Logger, dates DateRange, todayStr string) (*Timesheet, error) {
+	from, to := dates.From.Format("2006-01-02"), dates.To.Format("2006-01-02")
+	worklogs, err := GetMyWorklogs(settings, logger, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, worklog := range worklogs {
+		if key := strings.ToUpper(worklog.Issue); !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	details, err := GetIssueDetails(settings, logger, keys)
+	if err != nil {
+		return nil, err
+	}
+	epics := map[string]Epic{}
+	for key, issue := range details {
+		if epic, ok := IssueEpic(issue); ok {
+			epics[key] = epic
+		}
+	}
+
+	return BuildTimesheet(settings, dates, todayStr, worklogs, epics), nil
+}
+
+// parseReportFormat validates the --output of the report command
+func parseReportFormat(value string) (string, error) {
+	switch format := strings.ToLower(value); format {
+	case OutputCSV, OutputMarkdown:
+		return format, nil
+	case "md":
+		return OutputMarkdown, nil
+	}
+	format, err := ParseOutputFormat(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid --output %s, must be table, csv, markdown, json or yaml", value)
+	}
+	return format, nil
+}
+
+// markdownCellEscaper keeps text such as issue summaries within a single Markdown table cell
+var markdownCellEscaper = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ", "\r", " ")
+
+// renderTimesheet renders a timesheet as an aligned table, or as a Markdown table
+func renderTimesheet(t *Timesheet, by string, markdown bool) string {
+	var header []string
+	var rows [][]string
+	if by == "day" {
+		header = []string{"DATE", "DAY", "LOGGED", "TARGET", "GAP"}
+		for _, day := range t.Days {
+			gap := ""
+			switch {
+			case day.GapSeconds > 0:
+				gap = "missing " + FormatSeconds(day.GapSeconds)
+			case day.DayOff == DayOffHoliday:
+				gap = "holiday"
+			case day.DayOff == DayOffPTO:
+				gap = "PTO"
+			}
+			rows = append(rows, []string{day.Date, day.Weekday, FormatSeconds(day.Seconds), FormatSeconds(day.TargetSeconds), gap})
+		}
+	} else {
+		header = []string{strings.ToUpper(by), "LOGGED", "SHARE", "SUMMARY"}
+		for _, group := range t.Groups(by) {
+			share := 0
+			if t.TotalSeconds > 0 {
+				share = group.Seconds * 100 / t.TotalSeconds
+			}
+			rows = append(rows, []string{group.Name, FormatSeconds(group.Seconds), fmt.Sprintf("%d%%", share), group.Summary})
+		}
+	}
+
+	var b strings.Builder
+	title := fmt.Sprintf("Timesheet %s to %s, by %s", t.From, t.To, by)
+	summary := fmt.Sprintf("Total %s logged, %s expected up to today", FormatSeconds(t.TotalSeconds), FormatSeconds(t.TargetSeconds))
+	if t.GapSeconds > 0 {
+		summary += fmt.Sprintf(", %s missing", FormatSeconds(t.GapSeconds))
+	}
+
+	if markdown {
+		fmt.Fprintf(&b, "## %s\n\n", title)
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(header, " | "))
+		fmt.Fprintf(&b, "|%s\n", strings.Repeat("---|", len(header)))
+		for _, row := range rows {
+			if by == "day" && strings.HasPrefix(row[4], "missing") {
+				row[4] = "**" + row[4] + "**"
+			}
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = markdownCellEscaper.Replace(cell)
+			}
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+		}
+		fmt.Fprintf(&b, "\n%s\n", summary)
+		return b.String()
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	writeRow := func(row []string) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		fmt.Fprintf(&b, "%s\n", strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	fmt.Fprintf(&b, "%s\n\n", title)
+	writeRow(header)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	fmt.Fprintf(&b, "\n%s\n", summary)
+	return b.String()
+}
+
+// writeTimesheetCSV writes the rows of a grouping as CSV to stdout, durations in seconds and hours
+func writeTimesheetCSV(t *Timesheet, by string) error {
+	w := csv.NewWriter(os.Stdout)
+	hours := func(seconds int) string {
+		return fmt.Sprintf("%.2f", float64(seconds)/3600)
+	}
+	if by == "day" {
+		w.Write([]string{"date", "weekday", "seconds", "hours", "target_seconds", "gap_seconds"})
+		for _, day := range t.Days {
+			w.Write([]string{day.Date, day.Weekday, fmt.Sprint(day.Seconds), hours(day.Seconds), fmt.Sprint(day.TargetSeconds), fmt.Sprint(day.GapSeconds)})
+		}
+	} else {
+		w.Write([]string{by, "summary", "seconds", "hours"})
+		for _, group := range t.Groups(by) {
+			w.Write([]string{group.Name, group.Summary, fmt.Sprint(group.Seconds), hours(group.Seconds)})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDateRange(t *testing.T) {
+	// Today is Wednesday 2025-09-10
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "today", want: "2025-09-10"},
+		{spec: "yesterday", want: "2025-09-09"},
+		{spec: "this-week", want: "2025-09-08..2025-09-14"},
+		{spec: "Last-Week", want: "2025-09-01..2025-09-07"},
+		{spec: "this-month", want: "2025-09-01..2025-09-30"},
+		{spec: "last-month", want: "2025-08-01..2025-08-31"},
+		{spec: "2025-W01", want: "2024-12-30..2025-01-05"},
+		{spec: "2025-09-01", want: "2025-09-01"},
+		{spec: "2025-09-01..2025-09-14", want: "2025-09-01..2025-09-14"},
+		{spec: "2025-09-14..2025-09-01", wantErr: true},
+		{spec: "2025-02-30", wantErr: true},
+		{spec: "fortnight", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDateRange(tt.spec, "2025-09-10")
+		if (err != nil) != tt.wantErr || (err == nil && got.String() != tt.want) {
+			t.Errorf("ParseDateRange(%q) = %v, %v, want %s", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+// testTimesheet builds the timesheet of Monday to Wednesday 2025-09-08..10, with today on Tuesday
+func testTimesheet(t *testing.T) *Timesheet {
+	t.Helper()
+	settings := &Settings{}
+	settings.DailyTarget = "7.5h"
+	settings.CategoryAliases = map[string]string{"meetings": "PROJ-123", "standup": "proj-123"}
+
+	dates, err := ParseDateRange("2025-09-08..2025-09-10", "2025-09-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	worklogs := []ExistingWorklog{
+		{Date: "2025-09-08", Issue: "PROJ-123", Summary: "Team meetings", Seconds: 3600},
+		{Date: "2025-09-08", Issue: "PROJ-124", Summary: "Code review", Seconds: 23400},
+		{Date: "2025-09-09", Issue: "OPS-7", Summary: "On-call", Seconds: 7200},
+		{Date: "2025-09-10", Issue: "PROJ-124", Summary: "Code review", Seconds: 1800},
+	}
+	epics := map[string]Epic{
+		"PROJ-123": {Key: "PROJ-100", Summary: "Platform improvements"},
+		"PROJ-124": {Key: "PROJ-100", Summary: "Platform improvements"},
+	}
+	return BuildTimesheet(settings, dates, "2025-09-09", worklogs, epics)
+}
+
+func TestBuildTimesheet(t *testing.T) {
+	timesheet := testTimesheet(t)
+
+	wantDays := []TimesheetDay{
+		{Date: "2025-09-08", Weekday: "Mon", Seconds: 27000, TargetSeconds: 27000},
+		{Date: "2025-09-09", Weekday: "Tue", Seconds: 7200, TargetSeconds: 27000, GapSeconds: 19800},
+		{Date: "2025-09-10", Weekday: "Wed", Seconds: 1800, TargetSeconds: 27000}, // After today, no gap
+	}
+	if !reflect.DeepEqual(timesheet.Days, wantDays) {
+		t.Errorf("days\n got %+v\nwant %+v", timesheet.Days, wantDays)
+	}
+	if timesheet.TotalSeconds != 36000 || timesheet.TargetSeconds != 54000 || timesheet.GapSeconds != 19800 {
+		t.Errorf("totals %d, target %d, gap %d", timesheet.TotalSeconds, timesheet.TargetSeconds, timesheet.GapSeconds)
+	}
+
+	groups := map[string][]TimesheetGroup{
+		"issue": {
+			{Name: "PROJ-124", Summary: "Code review", Seconds: 25200},
+			{Name: "OPS-7", Summary: "On-call", Seconds: 7200},
+			{Name: "PROJ-123", Summary: "Team meetings", Seconds: 3600},
+		},
+		"epic": {
+			{Name: "PROJ-100", Summary: "Platform improvements", Seconds: 28800},
+			{Name: noEpicGroup, Seconds: 7200},
+		},
+		"project": {
+			{Name: "PROJ", Seconds: 28800},
+			{Name: "OPS", Seconds: 7200},
+		},
+		"alias": {
+			{Name: noAliasGroup, Seconds: 32400},
+			{Name: "meetings, standup", Summary: "PROJ-123", Seconds: 3600},
+		},
+	}
+	for by, want := range groups {
+		if got := timesheet.Groups(by); !reflect.DeepEqual(got, want) {
+			t.Errorf("by %s\n got %+v\nwant %+v", by, got, want)
+		}
+	}
+}
+
+func TestRenderTimesheet(t *testing.T) {
+	timesheet := testTimesheet(t)
+
+	tests := []struct {
+		name     string
+		by       string
+		markdown bool
+		want     string
+	}{
+		{
+			name: "table by day",
+			by:   "day",
+			want: `Timesheet 2025-09-08 to 2025-09-10, by day
+
+DATE        DAY  LOGGED  TARGET  GAP
+2025-09-08  Mon  7h30m   7h30m
+2025-09-09  Tue  2h0m    7h30m   missing 5h30m
+2025-09-10  Wed  0h30m   7h30m
+
+Total 10h0m logged, 15h0m expected up to today, 5h30m missing
+`,
+		},
+		{
+			name:     "markdown by epic",
+			by:       "epic",
+			markdown: true,
+			want: `## Timesheet 2025-09-08 to 2025-09-10, by epic
+
+| EPIC | LOGGED | SHARE | SUMMARY |
+|---|---|---|---|
+| PROJ-100 | 8h0m | 80% | Platform improvements |
+| (no epic) | 2h0m | 20% |  |
+
+Total 10h0m logged, 15h0m expected up to today, 5h30m missing
+`,
+		},
+		{
+			name:     "markdown by day",
+			by:       "day",
+			markdown: true,
+			want: `## Timesheet 2025-09-08 to 2025-09-10, by day
+
+| DATE | DAY | LOGGED | TARGET | GAP |
+|---|---|---|---|---|
+| 2025-09-08 | Mon | 7h30m | 7h30m |  |
+| 2025-09-09 | Tue | 2h0m | 7h30m | **missing 5h30m** |
+| 2025-09-10 | Wed | 0h30m | 7h30m |  |
+
+Total 10h0m logged, 15h0m expected up to today, 5h30m missing
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderTimesheet(timesheet, tt.by, tt.markdown); got != tt.want {
+				t.Errorf("renderTimesheet\n got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// Summaries can't break out of their Markdown cell
+	timesheet.Issues[2].Summary = "Meetings | 1:1s\nand standups"
+	got := renderTimesheet(timesheet, "issue", true)
+	if want := "| PROJ-123 | 1h0m | 10% | Meetings \\| 1:1s and standups |\n"; !strings.Contains(got, want) {
+		t.Errorf("renderTimesheet\n got:\n%s\nwant a row %q", got, want)
+	}
+}
+
+func TestWriteTimesheetCSV(t *testing.T) {
+	timesheet := testTimesheet(t)
+	output := captureStdout(t, func() {
+		if err := writeTimesheetCSV(timesheet, "project"); err != nil {
+			t.Fatalf("writeTimesheetCSV: %v", err)
+		}
+	})
+	want := "project,summary,seconds,hours\nPROJ,,28800,8.00\nOPS,,7200,2.00\n"
+	if output != want {
+		t.Errorf("CSV\n got %q\nwant %q", output, want)
+	}
+}
+
+func TestFetchTimesheet(t *testing.T) {
+	settings := newTestSettings(t, "defaults:\n  daily_target: 7.5h\n")
+	dates, _ := ParseDateRange("2025-W37", "2025-09-10")
+
+	timesheet, err := FetchTimesheet(settings, NewLogger("error"), dates, "2025-09-10")
+	if err != nil {
+		t.Fatalf("FetchTimesheet: %v", err)
+	}
+	// The default fixture has an hour of code review on Monday, linked to the epic PROJ-100
+	if timesheet.TotalSeconds != 3600 || timesheet.Days[0].Seconds != 3600 {
+		t.Errorf("unexpected totals %+v", timesheet)
+	}
+	want := []TimesheetGroup{{Name: "PROJ-100", Summary: "Epic: PROJ-100", Seconds: 3600}}
+	if !reflect.DeepEqual(timesheet.Epics, want) {
+		t.Errorf("epics\n got %+v\nwant %+v", timesheet.Epics, want)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// failedFile is the name of the file holding the failed entries of the last run in the state directory
+const failedFile = "failed.json"
+
+// FailedEntry is an entry that could not be posted, with the reason it failed
+type FailedEntry struct {
+	Entry     TimeEntry `json:"entry"`
+	Code      int       `json:"code"`
+	Kind      string    `json:"kind"`
+	Retryable bool      `json:"retryable"`
+	Reason    string    `json:"reason"`
+}
+
+// FailedRun holds the failed entries of a run so they can be retried
+type FailedRun struct {
+	Submission string        `json:"submission"`
+	Timestamp  time.Time     `json:"timestamp"`
+	Profile    string        `json:"profile"`
+	Entries    []FailedEntry `json:"entries"`
+}
+
+// NewFailedEntry classifies a failed worklog result
+func NewFailedEntry(entry TimeEntry, result WorklogResult) FailedEntry {
+	kind, retryable := ClassifyFailure(result.Code)
+	reason := result.Message
+	if reason == "" {
+		reason = result.Body
+	}
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	return FailedEntry{
+		Entry:     entry,
+		Code:      result.Code,
+		Kind:      kind,
+		Retryable: retryable,
+		Reason:    reason,
+	}
+}
+
+// SaveFailedRun replaces the saved failures with those of the given run, or removes them when there are none
+func SaveFailedRun(run FailedRun) error {
+	path, err := StatePath(failedFile)
+	if err != nil {
+		return err
+	}
+	unlock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if len(run.Entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", path, err)
+		}
+		return nil
+	}
+	return WriteJSONFile(path, run)
+}
+
+// LoadFailedRun loads the saved failures, returning nil when there are none
+func LoadFailedRun() (*FailedRun, error) {
+	path, err := StatePath(failedFile)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &FailedRun{}
+	if err := ReadJSONFile(path, run); err != nil {
+		return nil, err
+	}
+	if len(run.Entries) == 0 {
+		return nil, nil
+	}
+	return run, nil
+}
+
+// describeFailure formats the status and kind of a failure for reports, e.g. "HTTP 400 (validation, not retryable)"
+func describeFailure(failed FailedEntry) string {
+	status := fmt.Sprintf("HTTP %d", failed.Code)
+	if failed.Code == 0 {
+		status = "no response"
+	}
+	if failed.Retryable {
+		return fmt.Sprintf("%s (%s, retryable)", status, failed.Kind)
+	}
+	return fmt.Sprintf("%s (%s, not retryable)", status, failed.Kind)
+}
+
+// runRetry re-posts the retryable entries that failed in the previous run. With --all,
+// entries that failed with auth or validation errors are posted again too.
+func runRetry(args []string) int {
+	all := false
+	for _, arg := range args {
+		if arg != "--all" {
+			fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger retry [--all]")
+			return ExitConfig
+		}
+		all = true
+	}
+
+	settings, logger := setup()
+
+	run, err := LoadFailedRun()
+	if err != nil {
+		logger.Error("%v", err)
+		return ExitFailure
+	}
+	if run == nil {
+		logger.Info("No failed entries to retry.")
+		return 0
+	}
+	if run.Profile != settings.Profile() {
+		logger.Error("Failed entries were posted as %s, but the current profile is %s", run.Profile, settings.Profile())
+		return ExitConfig
+	}
+
+	submission := NewSubmissionID()
+	remaining := []FailedEntry{}
+	posted := 0
+
+	for _, failed := range run.Entries {
+		if !failed.Retryable && !all {
+			logger.Warn("  - %s %s: %s, skipped, use --all to post it anyway", failed.Entry.Date, failed.Entry.Issue, describeFailure(failed))
+			remaining = append(remaining, failed)
+			continue
+		}
+
+		result := PostWorklog(settings, logger, failed.Entry)
+		if result.Success {
+			if posted == 0 {
+				RecordSubmission(settings, logger, submission, "retry of "+run.Submission)
+			}
+			RecordPosted(settings, logger, submission, result)
+			logger.Info("  - %s %s: %s", failed.Entry.Date, failed.Entry.Issue, FormatSeconds(failed.Entry.Seconds))
+			posted++
+			continue
+		}
+
+		failedAgain := NewFailedEntry(failed.Entry, result)
+		logger.Error("  - %s %s: %s\n    %s", failed.Entry.Date, failed.Entry.Issue, describeFailure(failedAgain), failedAgain.Reason)
+		remaining = append(remaining, failedAgain)
+	}
+
+	run.Entries = remaining
+	if err := SaveFailedRun(*run); err != nil {
+		logger.Error("Failed to update failed entries: %v", err)
+		return ExitFailure
+	}
+
+	logger.Info("Posted %d of %d failed entries.", posted, posted+len(remaining))
+	return ExitCodeForFailures(posted, remaining)
+}
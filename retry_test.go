@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFailedEntry(t *testing.T) {
+	tests := []struct {
+		code      int
+		kind      string
+		retryable bool
+	}{
+		{0, FailureNetwork, true},
+		{400, FailureValidation, false},
+		{401, FailureAuth, false},
+		{403, FailureAuth, false},
+		{404, FailureValidation, false},
+		{408, FailureNetwork, true},
+		{429, FailureRateLimit, true},
+		{500, FailureServer, true},
+		{503, FailureServer, true},
+	}
+	for _, tt := range tests {
+		failed := NewFailedEntry(TimeEntry{Issue: "PROJ-1"}, WorklogResult{Code: tt.code, Body: "error"})
+		if failed.Kind != tt.kind || failed.Retryable != tt.retryable || failed.Code != tt.code {
+			t.Errorf("NewFailedEntry(HTTP %d) = %s, %v, want %s, %v", tt.code, failed.Kind, failed.Retryable, tt.kind, tt.retryable)
+		}
+	}
+
+	failed := NewFailedEntry(TimeEntry{}, WorklogResult{Code: 500, Body: strings.Repeat("x", 2000)})
+	if len(failed.Reason) != 500 {
+		t.Errorf("expected the reason to be truncated to 500 bytes, got %d", len(failed.Reason))
+	}
+}
+
+func TestSaveFailedRun(t *testing.T) {
+	t.Setenv("WORKLOG_STATE_DIR", t.TempDir())
+
+	if run, err := LoadFailedRun(); run != nil || err != nil {
+		t.Fatalf("LoadFailedRun without saved failures = %+v, %v", run, err)
+	}
+
+	entry := TimeEntry{Date: "2025-09-08", Issue: "PROJ-124", Seconds: 3600, Start: "09:00", Comment: "Review"}
+	run := FailedRun{
+		Submission: "20250908-170000-abcd",
+		Timestamp:  time.Date(2025, 9, 8, 17, 0, 0, 0, time.UTC),
+		Profile:    "jane@example.atlassian.net",
+		Entries:    []FailedEntry{NewFailedEntry(entry, WorklogResult{Code: 503, Body: "unavailable"})},
+	}
+	if err := SaveFailedRun(run); err != nil {
+		t.Fatalf("SaveFailedRun: %v", err)
+	}
+	loaded, err := LoadFailedRun()
+	if err != nil || loaded == nil {
+		t.Fatalf("LoadFailedRun = %+v, %v", loaded, err)
+	}
+	if loaded.Submission != run.Submission || loaded.Profile != run.Profile || len(loaded.Entries) != 1 ||
+		loaded.Entries[0].Entry != entry || loaded.Entries[0].Kind != FailureServer {
+		t.Errorf("LoadFailedRun = %+v, want %+v", loaded, run)
+	}
+
+	// Saving a run without failures clears the saved ones
+	if err := SaveFailedRun(FailedRun{}); err != nil {
+		t.Fatalf("SaveFailedRun: %v", err)
+	}
+	if loaded, err := LoadFailedRun(); loaded != nil || err != nil {
+		t.Errorf("expected no saved failures, got %+v, %v", loaded, err)
+	}
+}
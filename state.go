@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lock acquisition settings for state files
+const (
+	lockRetryInterval = 100 * time.Millisecond
+	lockTimeout       = 10 * time.Second
+	lockStaleAfter    = 2 * time.Minute
+)
+
+// StateDir returns the directory holding local state such as the timer, creating it if needed.
+// It can be overridden with the WORKLOG_STATE_DIR environment variable.
+func StateDir() (string, error) {
+	dir := os.Getenv("WORKLOG_STATE_DIR")
+	if dir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate state directory: %v", err)
+		}
+		dir = filepath.Join(configDir, "jira-worklogger")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create state directory: %v", err)
+	}
+	return dir, nil
+}
+
+// StatePath returns the path of a file in the state directory
+func StatePath(name string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// LockFile takes an exclusive lock on path using a sibling lock file and returns
+// a function releasing it. Locks left behind by a crashed process expire after a while.
+func LockFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+		}
+
+		// Break locks that are older than any legitimate holder
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			breakStaleLock(lockPath, info)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s", lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// breakStaleLock removes a lock file found stale. It is renamed aside first, so that of several
+// processes breaking it at once only one succeeds, and put back if another process broke it and
+// took the lock since it was found stale.
+func breakStaleLock(lockPath string, stale os.FileInfo) {
+	asidePath := fmt.Sprintf("%s.stale.%d", lockPath, os.Getpid())
+	if err := os.Rename(lockPath, asidePath); err != nil {
+		return // Already broken by another process
+	}
+	if aside, err := os.Stat(asidePath); err == nil && (!os.SameFile(stale, aside) || !aside.ModTime().Equal(stale.ModTime())) {
+		os.Link(asidePath, lockPath) // Fails without harm if yet another lock was taken
+	}
+	os.Remove(asidePath)
+}
+
+// ReadJSONFile decodes a JSON state file into v, leaving v untouched when the file doesn't exist
+func ReadJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return nil
+}
+
+// WriteJSONFile atomically replaces a JSON state file with v
+func WriteJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", path, err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	unlock, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile: %v", err)
+	}
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Fatalf("expected a lock file: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("expected the lock file to be removed, got %v", err)
+	}
+
+	// A lock left behind by a crashed process is broken once it is stale
+	if err := os.WriteFile(path+".lock", []byte("1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStaleAfter)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err = LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile with a stale lock: %v", err)
+	}
+	unlock()
+}
+
+func TestBreakStaleLock(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "state.json.lock")
+	if err := os.WriteFile(lockPath, []byte("1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.Stat(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process broke the stale lock and took a new one before this one got to it
+	if err := os.Rename(lockPath, lockPath+".old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lockPath, []byte("2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	breakStaleLock(lockPath, stale)
+	if data, err := os.ReadFile(lockPath); err != nil || string(data) != "2\n" {
+		t.Errorf("expected the new lock to be kept, got %q, %v", data, err)
+	}
+
+	// The stale lock itself is removed
+	current, _ := os.Stat(lockPath)
+	breakStaleLock(lockPath, current)
+	breakStaleLock(lockPath, current) // Already broken
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("expected the stale lock to be removed, got %v", err)
+	}
+	if matches, _ := filepath.Glob(lockPath + ".stale.*"); len(matches) != 0 {
+		t.Errorf("expected no lock left aside, got %v", matches)
+	}
+}
+
+func TestJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state := TimerState{Finished: []TimerSegment{{Issue: "PROJ-1"}}}
+	if err := ReadJSONFile(path, &state); err != nil || len(state.Finished) != 1 {
+		t.Fatalf("reading a missing file should leave the value untouched, got %+v, %v", state, err)
+	}
+
+	if err := WriteJSONFile(path, TimerState{Finished: []TimerSegment{{Issue: "PROJ-2"}}}); err != nil {
+		t.Fatalf("WriteJSONFile: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be renamed, got %v", err)
+	}
+	state = TimerState{}
+	if err := ReadJSONFile(path, &state); err != nil || len(state.Finished) != 1 || state.Finished[0].Issue != "PROJ-2" {
+		t.Errorf("ReadJSONFile = %+v, %v", state, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadJSONFile(path, &state); err == nil {
+		t.Error("expected a parse error")
+	}
+}
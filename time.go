@@ -3,17 +3,66 @@ package main
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
 )
 
 // TimeEntry represents a parsed time entry
 type TimeEntry struct {
-	Issue   string
-	Seconds int
+	Date       string           `json:"date"`
+	Issue      string           `json:"issue"`
+	Alias      string           `json:"alias,omitempty"` // Category alias the issue was resolved from
+	Seconds    int              `json:"seconds"`
+	Start      string           `json:"start,omitempty"` // Optional local start time (HH:MM), defaults to 17:00
+	Comment    string           `json:"comment,omitempty"`
+	Visibility *jira.Visibility `json:"visibility,omitempty"`
+	Started    string           `json:"started,omitempty"`   // ISO8601 start timestamp, resolved before posting
+	Recurring  string           `json:"recurring,omitempty"` // Name of the recurring rule that added the entry
+	Remainder  bool             `json:"-"`                   // Takes the rest of the day's target, see ResolveRemainders
+}
+
+// DayEntries groups the time entries logged against a single date
+type DayEntries struct {
+	Date    string
+	Entries []TimeEntry
 }
 
+// clockPattern matches a local time of day such as "09:30"
+var clockPattern = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+
+// EntryError reports a time entry that could not be parsed, with the line it appeared on
+type EntryError struct {
+	Line  int
+	Entry string
+	Err   error
+}
+
+func (e *EntryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// weekdayNames maps the day names accepted in date headers to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+	"sun": time.Sunday, "sunday": time.Sunday,
+}
+
+// dateHeaderPattern matches a day header such as "2025-09-08:" or "mon:" at the start of an entry
+var dateHeaderPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}|[A-Za-z]+)\s*:\s*(.*)$`)
+
 // DefaultDateStr returns the current date in YYYY-MM-DD format
 func DefaultDateStr() string {
 	now := time.Now()
@@ -25,29 +74,133 @@ func ParseDate(dateStr string) (time.Time, error) {
 	if dateStr == "" {
 		dateStr = DefaultDateStr()
 	}
-	
+
 	return time.Parse("2006-01-02", dateStr)
 }
 
-// ISOStartForDate creates an ISO8601 timestamp for the given date with the specified hour and minute
-func ISOStartForDate(dateStr string, hour, minute int, timezone string, logger *Logger) (string, error) {
-	date, err := ParseDate(dateStr)
-	if err != nil {
-		return "", err
+// ResolveDayHeader resolves a date header (YYYY-MM-DD or a weekday name) to a date string.
+// Weekday names refer to the most recent such day on or before the base date.
+// The boolean result is false when the header is not a date header at all.
+func ResolveDayHeader(header, baseDateStr string) (string, bool, error) {
+	if weekday, ok := weekdayNames[strings.ToLower(header)]; ok {
+		base, err := ParseDate(baseDateStr)
+		if err != nil {
+			return "", true, err
+		}
+		offset := (int(base.Weekday()) - int(weekday) + 7) % 7
+		return base.AddDate(0, 0, -offset).Format("2006-01-02"), true, nil
+	}
+
+	if len(header) == len("2006-01-02") && header[4] == '-' {
+		if _, err := ParseDate(header); err != nil {
+			return "", true, fmt.Errorf("invalid date header %s: %v", header, err)
+		}
+		return header, true, nil
+	}
+
+	return "", false, nil
+}
+
+// GroupEntriesByDate groups time entries by date, ordered by date
+func GroupEntriesByDate(entries []TimeEntry) []DayEntries {
+	days := []DayEntries{}
+	index := map[string]int{}
+
+	for _, entry := range entries {
+		i, ok := index[entry.Date]
+		if !ok {
+			i = len(days)
+			index[entry.Date] = i
+			days = append(days, DayEntries{Date: entry.Date})
+		}
+		days[i].Entries = append(days[i].Entries, entry)
 	}
 
-	// Get timezone location
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+	return days
+}
+
+// isAliasEntry reports whether a name and the text after its colon are an alias and a duration
+func isAliasEntry(name, rest string, aliases map[string]string) bool {
+	if _, ok := aliases[strings.ToLower(name)]; !ok || strings.TrimSpace(rest) == "" {
+		return false
+	}
+	_, err := ToTimeSpentSeconds(rest)
+	return err == nil
+}
+
+// ParseClock parses a local time of day in HH:MM format
+func ParseClock(clockStr string) (int, int, error) {
+	matches := clockPattern.FindStringSubmatch(strings.TrimSpace(clockStr))
+	if matches == nil {
+		return 0, 0, fmt.Errorf("invalid start time %q, expected HH:MM", clockStr)
+	}
+	hour, _ := strconv.Atoi(matches[1])
+	minute, _ := strconv.Atoi(matches[2])
+	if hour > 23 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid start time %q, expected HH:MM", clockStr)
+	}
+	return hour, minute, nil
+}
+
+// ResolveStartedISO sets the ISO8601 start timestamp of every entry from its date and
+// optional start time, so that invalid dates are reported before anything is posted
+func ResolveStartedISO(days []DayEntries, timezone string, logger *Logger) error {
+	for i := range days {
+		for j := range days[i].Entries {
+			entry := &days[i].Entries[j]
+
+			hour, minute := 17, 0
+			if entry.Start != "" {
+				var err error
+				if hour, minute, err = ParseClock(entry.Start); err != nil {
+					return fmt.Errorf("%s on %s: %v", entry.Issue, entry.Date, err)
+				}
+			}
+
+			startedISO, err := ISOStartForDate(entry.Date, hour, minute, timezone, logger)
+			if err != nil {
+				return fmt.Errorf("failed to parse date %s: %v", entry.Date, err)
+			}
+			entry.Started = startedISO
+		}
+	}
+	return nil
+}
+
+// LoadTimezone loads the configured timezone, falling back to the system default
+func LoadTimezone(timezone string, logger *Logger) *time.Location {
 	loc, err := time.LoadLocation(timezone)
 	if err != nil {
 		logger.Warn("Could not load timezone %s: %v, using system default", timezone, err)
-		loc = time.Local
+		return time.Local
+	}
+	return loc
+}
+
+// ResolveAlias returns the issue key for a category alias, or the name itself when it isn't an alias
+func ResolveAlias(name string, aliases map[string]string, logger *Logger) string {
+	if aliasValue, ok := aliases[strings.ToLower(name)]; ok {
+		logger.Info("Using alias '%s' -> %s", name, aliasValue)
+		return aliasValue
+	}
+	return name
+}
+
+// ISOStartForDate creates an ISO8601 timestamp for the given date with the specified hour and minute
+func ISOStartForDate(dateStr string, hour, minute int, timezone string, logger *Logger) (string, error) {
+	date, err := ParseDate(dateStr)
+	if err != nil {
+		return "", err
 	}
 
 	// Set time components
-	date = time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
-	
+	date = time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, LoadTimezone(timezone, logger))
+
 	// Format with timezone offset
-	return date.Format("2006-01-02T15:04:05.000-0700"), nil
+	return date.Format(jira.TimeLayout), nil
 }
 
 // ToTimeSpentSeconds converts a time string to seconds
@@ -96,24 +249,59 @@ func ToTimeSpentSeconds(timeStr string) (int, error) {
 	return 0, fmt.Errorf("unable to parse time: %s", timeStr)
 }
 
-// ParseTimeEntries parses a time entry string into issue keys and durations
-func ParseTimeEntries(entriesStr string, aliases map[string]string, logger *Logger) ([]TimeEntry, error) {
+// ParseTimeEntries parses a time entry string into issue keys and durations.
+// Entries are logged against dateStr unless preceded by a date header such as
+// "2025-09-08:" or "mon:", which applies until the next header.
+func ParseTimeEntries(entriesStr string, dateStr string, aliases map[string]string, logger *Logger) ([]TimeEntry, error) {
 	entries := []TimeEntry{}
 	if entriesStr == "" {
 		return entries, nil
 	}
 
-	// Split entries by semicolon or newline
-	items := []string{}
-	for _, line := range strings.Split(entriesStr, "\n") {
-		for _, part := range strings.Split(line, ";") {
+	if dateStr == "" {
+		dateStr = DefaultDateStr()
+	}
+	if _, err := ParseDate(dateStr); err != nil {
+		return nil, fmt.Errorf("invalid date %s: %v", dateStr, err)
+	}
+	currentDate := dateStr
+
+	// Split entries by semicolon or newline, remembering the line each came from
+	type entryItem struct {
+		line int
+		text string
+	}
+	items := []entryItem{}
+	for lineNo, line := range strings.Split(entriesStr, "\n") {
+		// Skip comment lines
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, part := range splitEntryItems(line) {
 			if part = strings.TrimSpace(part); part != "" {
-				items = append(items, part)
+				items = append(items, entryItem{line: lineNo + 1, text: part})
 			}
 		}
 	}
 
-	for _, item := range items {
+	for _, it := range items {
+		item := it.text
+
+		// Switch date when the entry starts with a date header. An alias named like a weekday
+		// followed by a duration ("fri: 1h") is an entry, not a header.
+		if matches := dateHeaderPattern.FindStringSubmatch(item); matches != nil && !isAliasEntry(matches[1], matches[2], aliases) {
+			day, isHeader, err := ResolveDayHeader(matches[1], dateStr)
+			if err != nil {
+				return nil, &EntryError{Line: it.line, Entry: item, Err: err}
+			}
+			if isHeader {
+				currentDate = day
+				if item = strings.TrimSpace(matches[2]); item == "" {
+					continue
+				}
+			}
+		}
+
 		// Extract issue and time value
 		var issue, timeValue string
 
@@ -124,7 +312,7 @@ func ParseTimeEntries(entriesStr string, aliases map[string]string, logger *Logg
 		} else if parts := strings.Fields(item); len(parts) >= 2 {
 			// Format: ISSUE TIME
 			issue = parts[0]
-			timeValue = parts[1]
+			timeValue = strings.TrimSpace(strings.TrimPrefix(item, issue))
 		} else if len(parts) == 1 {
 			// Only issue key, treat as zero
 			issue = parts[0]
@@ -133,25 +321,121 @@ func ParseTimeEntries(entriesStr string, aliases map[string]string, logger *Logg
 			continue
 		}
 
+		// Options following the duration: @start, [type:visibility] and "comment"
+		timeValue, options, err := parseEntryOptions(timeValue)
+		if err != nil {
+			return nil, &EntryError{Line: it.line, Entry: item, Err: err}
+		}
+
 		// Remove trailing colon if present (may appear when clicking on epics)
 		issue = strings.TrimSuffix(issue, ":")
 
 		// Check for category alias
+		alias := ""
 		if aliasValue, ok := aliases[strings.ToLower(issue)]; ok {
 			logger.Info("Using alias '%s' -> %s", issue, aliasValue)
+			alias = issue
 			issue = aliasValue
 		}
 
 		// Parse time spent
 		seconds, err := ToTimeSpentSeconds(timeValue)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse time for entry %s: %v", item, err)
+			return nil, &EntryError{Line: it.line, Entry: item, Err: fmt.Errorf("failed to parse time for entry %s: %v", item, err)}
 		}
 
 		if issue != "" && seconds > 0 {
-			entries = append(entries, TimeEntry{Issue: issue, Seconds: seconds})
+			options.Date, options.Issue, options.Alias, options.Seconds = currentDate, issue, alias, seconds
+			entries = append(entries, options)
 		}
 	}
 
 	return entries, nil
 }
+
+// Options that may follow the duration of an entry, e.g. meetings=1h @09:30 [role:Developers] "Planning".
+// The comment comes last.
+var (
+	entryCommentPattern    = regexp.MustCompile(`(?:^|\s)"((?:[^"\\]|\\.)*)"\s*$`)
+	entryVisibilityPattern = regexp.MustCompile(`(?:^|\s)\[(\w+):([^\]]*)\]`)
+	entryStartPattern      = regexp.MustCompile(`(?:^|\s)@(\S+)`)
+)
+
+// parseEntryOptions splits the start time, visibility and comment off the text following the
+// issue of an entry, returning the duration left and an entry holding the options
+func parseEntryOptions(text string) (string, TimeEntry, error) {
+	var entry TimeEntry
+	if matches := entryCommentPattern.FindStringSubmatchIndex(text); matches != nil {
+		entry.Comment = unquoteComment(text[matches[2]:matches[3]])
+		text = text[:matches[0]]
+	}
+	if matches := entryVisibilityPattern.FindStringSubmatch(text); matches != nil {
+		visType, visValue := strings.ToLower(matches[1]), strings.TrimSpace(matches[2])
+		if visType != "group" && visType != "role" {
+			return "", entry, fmt.Errorf("invalid visibility %s, expected [role:NAME] or [group:NAME]", strings.TrimSpace(matches[0]))
+		}
+		if visValue == "" {
+			return "", entry, fmt.Errorf("invalid visibility %s, the %s name is missing", strings.TrimSpace(matches[0]), visType)
+		}
+		entry.Visibility = &jira.Visibility{Type: visType, Value: visValue}
+		text = strings.Replace(text, matches[0], "", 1)
+	}
+	if matches := entryStartPattern.FindStringSubmatch(text); matches != nil {
+		if _, _, err := ParseClock(matches[1]); err != nil {
+			return "", entry, err
+		}
+		entry.Start = matches[1]
+		text = strings.Replace(text, matches[0], "", 1)
+	}
+	if strings.Contains(text, "\"") {
+		return "", entry, fmt.Errorf("unterminated or misplaced comment, it must be quoted and come last")
+	}
+	return strings.TrimSpace(text), entry, nil
+}
+
+// splitEntryItems splits a line of entries on semicolons outside quoted comments
+func splitEntryItems(line string) []string {
+	items := []string{}
+	quoted, escaped := false, false
+	begin := 0
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			items = append(items, line[begin:i])
+			begin = i + 1
+		}
+	}
+	return append(items, line[begin:])
+}
+
+// quoteComment quotes a comment for the text entry format
+func quoteComment(comment string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(comment) + `"`
+}
+
+// unquoteComment reverses the escaping of quoteComment
+func unquoteComment(quoted string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range quoted {
+		switch {
+		case escaped && r == 'n':
+			b.WriteRune('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	return b.String()
+}
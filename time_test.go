@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+)
+
+func TestParseTimeEntries(t *testing.T) {
+	aliases := map[string]string{"meetings": "PROJ-123", "fri": "PROJ-456"}
+
+	// Entries default to Wednesday 2025-09-10
+	tests := []struct {
+		name    string
+		input   string
+		want    []TimeEntry
+		wantErr int // Line of the expected *EntryError, 0 for none
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  []TimeEntry{},
+		},
+		{
+			name:  "alias and issue key",
+			input: "meetings=1h30m; PROJ-124=45m",
+			want: []TimeEntry{
+				{Date: "2025-09-10", Issue: "PROJ-123", Alias: "meetings", Seconds: 5400},
+				{Date: "2025-09-10", Issue: "PROJ-124", Seconds: 2700},
+			},
+		},
+		{
+			name:  "space separated with a trailing colon",
+			input: "PROJ-124: 90m\nmeetings 2",
+			want: []TimeEntry{
+				{Date: "2025-09-10", Issue: "PROJ-124", Seconds: 5400},
+				{Date: "2025-09-10", Issue: "PROJ-123", Alias: "meetings", Seconds: 7200},
+			},
+		},
+		{
+			name:  "comment lines and zero durations are skipped",
+			input: "# planning\nPROJ-124=0\nPROJ-125\nmeetings=1:15",
+			want: []TimeEntry{
+				{Date: "2025-09-10", Issue: "PROJ-123", Alias: "meetings", Seconds: 4500},
+			},
+		},
+		{
+			name:  "date and weekday headers",
+			input: "2025-09-01: PROJ-124=1h\nmon:\nmeetings=30m\nthu: PROJ-124=2h; tue: PROJ-124=3h\nWednesday: PROJ-124=1h",
+			want: []TimeEntry{
+				{Date: "2025-09-01", Issue: "PROJ-124", Seconds: 3600},
+				{Date: "2025-09-08", Issue: "PROJ-123", Alias: "meetings", Seconds: 1800},
+				{Date: "2025-09-04", Issue: "PROJ-124", Seconds: 7200},
+				{Date: "2025-09-09", Issue: "PROJ-124", Seconds: 10800},
+				{Date: "2025-09-10", Issue: "PROJ-124", Seconds: 3600},
+			},
+		},
+		{
+			name:  "a colon after an alias is not a header",
+			input: "meetings: 1h",
+			want: []TimeEntry{
+				{Date: "2025-09-10", Issue: "PROJ-123", Alias: "meetings", Seconds: 3600},
+			},
+		},
+		{
+			name:  "an alias named like a weekday",
+			input: "fri: 1h\nfri: PROJ-124=2h",
+			want: []TimeEntry{
+				{Date: "2025-09-10", Issue: "PROJ-456", Alias: "fri", Seconds: 3600},
+				{Date: "2025-09-05", Issue: "PROJ-124", Seconds: 7200},
+			},
+		},
+		{
+			name:  "options",
+			input: `meetings=1h @9:30 "Planning; retro"; PROJ-124 1h 30m [group:jira-users]`,
+			want: []TimeEntry{
+				{Date: "2025-09-10", Issue: "PROJ-123", Alias: "meetings", Seconds: 3600, Start: "9:30", Comment: "Planning; retro"},
+				{Date: "2025-09-10", Issue: "PROJ-124", Seconds: 5400, Visibility: &jira.Visibility{Type: "group", Value: "jira-users"}},
+			},
+		},
+		{
+			name:    "invalid duration",
+			input:   "meetings=1h\nPROJ-124=lots",
+			wantErr: 2,
+		},
+		{
+			name:    "invalid start time",
+			input:   "meetings=1h @26:00",
+			wantErr: 1,
+		},
+		{
+			name:    "invalid visibility",
+			input:   "meetings=1h\nPROJ-124=1h [team:core]",
+			wantErr: 2,
+		},
+		{
+			name:    "comment not last",
+			input:   `meetings=1h "Planning" @9:30`,
+			wantErr: 1,
+		},
+		{
+			name:    "invalid date header",
+			input:   "meetings=1h\n\n2025-02-30: PROJ-124=1h",
+			wantErr: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimeEntries(tt.input, "2025-09-10", aliases, NewLogger("error"))
+			if tt.wantErr != 0 {
+				var entryErr *EntryError
+				if !errors.As(err, &entryErr) || entryErr.Line != tt.wantErr {
+					t.Fatalf("expected an entry error on line %d, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTimeEntries(%q): %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTimeEntries(%q)\n got %+v\nwant %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupEntriesByDate(t *testing.T) {
+	entries := []TimeEntry{
+		{Date: "2025-09-09", Issue: "PROJ-1", Seconds: 60},
+		{Date: "2025-09-08", Issue: "PROJ-2", Seconds: 60},
+		{Date: "2025-09-09", Issue: "PROJ-3", Seconds: 60},
+	}
+	days := GroupEntriesByDate(entries)
+	if len(days) != 2 || days[0].Date != "2025-09-08" || days[1].Date != "2025-09-09" {
+		t.Fatalf("expected the days ordered by date, got %+v", days)
+	}
+	if len(days[1].Entries) != 2 || days[1].Entries[1].Issue != "PROJ-3" {
+		t.Errorf("unexpected entries of %s: %+v", days[1].Date, days[1].Entries)
+	}
+}
+
+func TestToTimeSpentSeconds(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"1h30m", 5400, false},
+		{"1h 30m", 5400, false},
+		{"1.5h", 5400, false},
+		{"45m", 2700, false},
+		{"1:15", 4500, false},
+		{"2", 7200, false},
+		{"0.25", 900, false},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ToTimeSpentSeconds(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ToTimeSpentSeconds(%q) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestISOStartForDate(t *testing.T) {
+	tests := []struct {
+		timezone string
+		want     string
+	}{
+		{timezone: "Europe/London", want: "2025-09-08T09:30:00.000+0100"},
+		{timezone: "UTC", want: "2025-09-08T09:30:00.000+0000"},
+	}
+	for _, tt := range tests {
+		got, err := ISOStartForDate("2025-09-08", 9, 30, tt.timezone, NewLogger("error"))
+		if err != nil || got != tt.want {
+			t.Errorf("ISOStartForDate in %s = %q, %v, want %q", tt.timezone, got, err, tt.want)
+		}
+	}
+
+	// An unknown timezone falls back to the system one
+	got, err := ISOStartForDate("2025-09-08", 9, 30, "Nowhere/Atlantis", NewLogger("error"))
+	want := time.Date(2025, 9, 8, 9, 30, 0, 0, time.Local).Format(jira.TimeLayout)
+	if err != nil || got != want {
+		t.Errorf("ISOStartForDate in an unknown timezone = %q, %v, want %q", got, err, want)
+	}
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// timerStateFile is the name of the timer state file in the state directory
+const timerStateFile = "timer.json"
+
+// TimerSegment is a period of time tracked against an issue
+type TimerSegment struct {
+	Issue string     `json:"issue"`
+	Label string     `json:"label,omitempty"` // Alias or key as typed by the user
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// Duration returns the length of the segment, up to now when it is still running
+func (s TimerSegment) Duration(now time.Time) time.Duration {
+	if s.End != nil {
+		return s.End.Sub(s.Start)
+	}
+	return now.Sub(s.Start)
+}
+
+// TimerState is the persisted state of the timer
+type TimerState struct {
+	Running  *TimerSegment  `json:"running,omitempty"`
+	Finished []TimerSegment `json:"finished"`
+	Posting  []TimerSegment `json:"posting,omitempty"` // Claimed by a post in progress
+}
+
+// segmentKey identifies a segment in the timer state
+func segmentKey(segment TimerSegment) string {
+	return segment.Start.Format(time.RFC3339Nano) + " " + segment.Issue
+}
+
+// UpdateTimerState loads the timer state under a lock, applies fn and saves the result
+// unless fn returns an error
+func UpdateTimerState(fn func(state *TimerState) error) error {
+	path, err := StatePath(timerStateFile)
+	if err != nil {
+		return err
+	}
+	unlock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state := &TimerState{}
+	if err := ReadJSONFile(path, state); err != nil {
+		return err
+	}
+	if err := fn(state); err != nil {
+		return err
+	}
+	return WriteJSONFile(path, state)
+}
+
+// LoadTimerState reads the timer state without modifying it. The lock is only held while
+// reading, so a read never sees a state being updated.
+func LoadTimerState() (*TimerState, error) {
+	path, err := StatePath(timerStateFile)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := LockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	state := &TimerState{}
+	if err := ReadJSONFile(path, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// RoundSeconds rounds seconds to a multiple of increment. Mode is up, down or nearest.
+func RoundSeconds(seconds, increment int, mode string) int {
+	if increment <= 0 {
+		return seconds
+	}
+	remainder := seconds % increment
+	if remainder == 0 {
+		return seconds
+	}
+
+	switch mode {
+	case "up":
+		return seconds - remainder + increment
+	case "down":
+		return seconds - remainder
+	default:
+		if remainder*2 >= increment {
+			return seconds - remainder + increment
+		}
+		return seconds - remainder
+	}
+}
+
+// GroupTimerSegments groups finished segments per day and issue into time entries that start
+// at the first segment's real start time, rounding each total by the configured policy
+func GroupTimerSegments(segments []TimerSegment, timerConfig TimerConfig, loc *time.Location) ([]TimeEntry, error) {
+	increment := 60
+	if timerConfig.Rounding != "" {
+		seconds, err := ToTimeSpentSeconds(timerConfig.Rounding)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timer rounding: %v", err)
+		}
+		if seconds > 0 {
+			increment = seconds
+		}
+	}
+
+	type groupKey struct{ date, issue string }
+	groups := map[groupKey]*TimeEntry{}
+	firstStart := map[groupKey]time.Time{}
+	exact := map[groupKey]time.Duration{}
+
+	for _, segment := range segments {
+		start := segment.Start.In(loc)
+		key := groupKey{start.Format("2006-01-02"), segment.Issue}
+		if _, ok := groups[key]; !ok {
+			groups[key] = &TimeEntry{Date: key.date, Issue: segment.Issue}
+			if segment.Label != segment.Issue {
+				groups[key].Alias = segment.Label
+			}
+			firstStart[key] = start
+		}
+		if start.Before(firstStart[key]) {
+			firstStart[key] = start
+		}
+		exact[key] += segment.Duration(time.Now())
+	}
+
+	keys := make([]groupKey, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return firstStart[keys[i]].Before(firstStart[keys[j]])
+	})
+
+	entries := []TimeEntry{}
+	for _, key := range keys {
+		entry := groups[key]
+		entry.Start = firstStart[key].Format("15:04")
+		entry.Seconds = RoundSeconds(int(exact[key].Seconds()), increment, timerConfig.RoundingMode)
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// runTimerStart starts a timer for an alias or issue
+func runTimerStart(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger start <alias|issue>")
+		return ExitConfig
+	}
+	settings, logger := setup()
+
+	label := args[0]
+	issue := ResolveAlias(label, settings.CategoryAliases, logger)
+	now := time.Now()
+
+	err := UpdateTimerState(func(state *TimerState) error {
+		if state.Running != nil {
+			return fmt.Errorf("timer already running for %s since %s, use switch or stop", state.Running.Issue, state.Running.Start.In(LoadTimezone(settings.Timezone, logger)).Format("15:04"))
+		}
+		state.Running = &TimerSegment{Issue: issue, Label: label, Start: now}
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+
+	logger.Info("Started timer for %s at %s", issue, now.In(LoadTimezone(settings.Timezone, logger)).Format("15:04"))
+	return 0
+}
+
+// runTimerStop stops the running timer, keeping the segment for posting
+func runTimerStop(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger stop")
+		return ExitConfig
+	}
+	_, logger := setup()
+	now := time.Now()
+
+	var stopped TimerSegment
+	err := UpdateTimerState(func(state *TimerState) error {
+		if state.Running == nil {
+			return fmt.Errorf("no timer is running")
+		}
+		stopped = *state.Running
+		stopped.End = &now
+		state.Finished = append(state.Finished, stopped)
+		state.Running = nil
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+
+	logger.Info("Stopped timer for %s after %s", stopped.Issue, FormatSeconds(int(stopped.Duration(now).Seconds())))
+	return 0
+}
+
+// runTimerSwitch stops the running timer, if any, and starts one for another alias or issue
+func runTimerSwitch(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger switch <alias|issue>")
+		return ExitConfig
+	}
+	settings, logger := setup()
+
+	label := args[0]
+	issue := ResolveAlias(label, settings.CategoryAliases, logger)
+	now := time.Now()
+
+	var stopped *TimerSegment
+	err := UpdateTimerState(func(state *TimerState) error {
+		if state.Running != nil {
+			segment := *state.Running
+			segment.End = &now
+			state.Finished = append(state.Finished, segment)
+			stopped = &segment
+		}
+		state.Running = &TimerSegment{Issue: issue, Label: label, Start: now}
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+
+	if stopped != nil {
+		logger.Info("Stopped timer for %s after %s", stopped.Issue, FormatSeconds(int(stopped.Duration(now).Seconds())))
+	}
+	logger.Info("Started timer for %s at %s", issue, now.In(LoadTimezone(settings.Timezone, logger)).Format("15:04"))
+	return 0
+}
+
+// runTimerStatus shows the running timer and the finished segments waiting to be posted
+func runTimerStatus(args []string) int {
+	settings, logger := setup()
+	loc := LoadTimezone(settings.Timezone, logger)
+	now := time.Now()
+
+	state, err := LoadTimerState()
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+
+	if state.Running != nil {
+		fmt.Printf("Running: %s since %s (%s)\n", state.Running.Issue, state.Running.Start.In(loc).Format("2006-01-02 15:04"),
+			FormatSeconds(int(state.Running.Duration(now).Seconds())))
+	} else {
+		fmt.Println("Running: none")
+	}
+
+	if len(state.Posting) > 0 {
+		fmt.Printf("%d segment(s) are being posted. If no post is running, an interrupted one left them:\n"+
+			"check 'jira-worklogger history' and drop them with 'jira-worklogger discard --all'.\n", len(state.Posting))
+	}
+	if len(state.Finished) == 0 {
+		fmt.Println("No finished segments waiting to be posted.")
+		return 0
+	}
+
+	fmt.Println("\nFinished segments:")
+	for _, segment := range state.Finished {
+		fmt.Printf("  %s  %s-%s  %-12s %s\n", segment.Start.In(loc).Format("2006-01-02"), segment.Start.In(loc).Format("15:04"),
+			segment.End.In(loc).Format("15:04"), segment.Issue, FormatSeconds(int(segment.Duration(now).Seconds())))
+	}
+
+	entries, err := GroupTimerSegments(state.Finished, settings.Timer, loc)
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+	fmt.Println("\nWill be posted as:")
+	for _, entry := range entries {
+		fmt.Printf("  %s  %s  %-12s %s\n", entry.Date, entry.Start, entry.Issue, FormatSeconds(entry.Seconds))
+	}
+	return 0
+}
+
+// runTimerDiscard drops the running timer, or every unposted segment with --all
+func runTimerDiscard(args []string) int {
+	all := len(args) == 1 && args[0] == "--all"
+	if len(args) > 0 && !all {
+		fmt.Fprintln(os.Stderr, "[error] Usage: jira-worklogger discard [--all]")
+		return ExitConfig
+	}
+	_, logger := setup()
+
+	var discarded int
+	err := UpdateTimerState(func(state *TimerState) error {
+		if state.Running != nil {
+			discarded++
+			state.Running = nil
+		}
+		if all {
+			discarded += len(state.Finished) + len(state.Posting)
+			state.Finished = nil
+			state.Posting = nil
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+
+	if discarded == 0 {
+		logger.Info("Nothing to discard.")
+	} else {
+		logger.Info("Discarded %d segment(s).", discarded)
+	}
+	return 0
+}
+
+// runTimerPost posts the finished segments, grouped per day and issue, and removes the posted ones.
+// Groups that round to zero are dropped. The segments are claimed under the lock before posting,
+// so concurrent posts never post the same segment twice; the segments of failed groups are put back.
+func runTimerPost(args []string) int {
+	settings, logger := setup()
+	loc := LoadTimezone(settings.Timezone, logger)
+
+	var claimed []TimerSegment
+	var running *TimerSegment
+	err := UpdateTimerState(func(state *TimerState) error {
+		claimed = state.Finished
+		running = state.Running
+		state.Posting = append(state.Posting, claimed...)
+		state.Finished = []TimerSegment{}
+		return nil
+	})
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+	if len(claimed) == 0 {
+		logger.Info("No finished segments to post.")
+		return 0
+	}
+	if running != nil {
+		logger.Info("Timer for %s is still running and will not be posted.", running.Issue)
+	}
+
+	// Segments of groups that were not posted go back to the finished segments
+	posted := map[string]bool{}
+	release := func() error {
+		return UpdateTimerState(func(state *TimerState) error {
+			claimedKeys := map[string]bool{}
+			for _, segment := range claimed {
+				claimedKeys[segmentKey(segment)] = true
+				if !posted[segment.Start.In(loc).Format("2006-01-02")+" "+segment.Issue] {
+					state.Finished = append(state.Finished, segment)
+				}
+			}
+			remaining := []TimerSegment{}
+			for _, segment := range state.Posting {
+				if !claimedKeys[segmentKey(segment)] {
+					remaining = append(remaining, segment)
+				}
+			}
+			state.Posting = remaining
+			sort.SliceStable(state.Finished, func(i, j int) bool { return state.Finished[i].Start.Before(state.Finished[j].Start) })
+			return nil
+		})
+	}
+
+	// Nothing was posted, put every claimed segment back
+	abort := func(err error) int {
+		logger.Error("%v", err)
+		if err := release(); err != nil {
+			logger.Error("Failed to release the claimed timer segments, see 'jira-worklogger status': %v", err)
+		}
+		return 1
+	}
+
+	entries, err := GroupTimerSegments(claimed, settings.Timer, loc)
+	if err != nil {
+		return abort(err)
+	}
+	days := GroupEntriesByDate(entries)
+	if err := ResolveStartedISO(days, settings.Timezone, logger); err != nil {
+		return abort(err)
+	}
+	WarnNonWorkingDays(settings, logger, days)
+
+	// Post outside the lock so other timer commands aren't blocked by slow requests
+	submission := NewSubmissionID()
+	postedCount := 0
+	failures := []FailedEntry{}
+	for _, day := range days {
+		for _, entry := range day.Entries {
+			if entry.Seconds <= 0 {
+				logger.Warn("Dropping %s on %s, rounded to zero", entry.Issue, entry.Date)
+				posted[entry.Date+" "+entry.Issue] = true
+				continue
+			}
+			result := PostWorklog(settings, logger, entry)
+			if result.Success {
+				if postedCount == 0 {
+					RecordSubmission(settings, logger, submission, "timer post")
+				}
+				RecordPosted(settings, logger, submission, result)
+				posted[entry.Date+" "+entry.Issue] = true
+				postedCount++
+				logger.Info("  - %s %s %s: %s", entry.Date, entry.Start, entry.Issue, FormatSeconds(entry.Seconds))
+			} else {
+				failed := NewFailedEntry(entry, result)
+				failures = append(failures, failed)
+				logger.Error("  - %s %s: %s\n    %s", entry.Date, entry.Issue, describeFailure(failed), failed.Reason)
+			}
+		}
+	}
+
+	if err := release(); err != nil {
+		logger.Error("Posted worklogs but failed to update timer state: %v", err)
+		return 1
+	}
+
+	logger.Info("Posted %d worklog(s) from the timer.", postedCount)
+	return ExitCodeForFailures(postedCount, failures)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/liam-witterick/jira-worklogger/mockjira"
+)
+
+func TestRoundSeconds(t *testing.T) {
+	tests := []struct {
+		seconds   int
+		increment int
+		mode      string
+		want      int
+	}{
+		{1000, 900, "up", 1800},
+		{1000, 900, "down", 900},
+		{1000, 900, "nearest", 900},
+		{1350, 900, "nearest", 1800},
+		{1800, 900, "up", 1800},
+		{1000, 0, "up", 1000},
+	}
+	for _, tt := range tests {
+		if got := RoundSeconds(tt.seconds, tt.increment, tt.mode); got != tt.want {
+			t.Errorf("RoundSeconds(%d, %d, %q) = %d, want %d", tt.seconds, tt.increment, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestGroupTimerSegments(t *testing.T) {
+	loc := time.FixedZone("BST", 3600)
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	segment := func(issue, start, end string) TimerSegment {
+		stop := at(end)
+		return TimerSegment{Issue: issue, Start: at(start), End: &stop}
+	}
+
+	segments := []TimerSegment{
+		segment("PROJ-124", "2025-09-08 10:00", "2025-09-08 10:20"),
+		segment("PROJ-123", "2025-09-08 09:05", "2025-09-08 09:50"),
+		segment("PROJ-124", "2025-09-08 11:00", "2025-09-08 11:07"),
+		segment("PROJ-124", "2025-09-09 09:00", "2025-09-09 09:01"),
+	}
+	entries, err := GroupTimerSegments(segments, TimerConfig{Rounding: "15m", RoundingMode: "up"}, loc)
+	if err != nil {
+		t.Fatalf("GroupTimerSegments: %v", err)
+	}
+	want := []TimeEntry{
+		{Date: "2025-09-08", Issue: "PROJ-123", Start: "09:05", Seconds: 2700},
+		{Date: "2025-09-08", Issue: "PROJ-124", Start: "10:00", Seconds: 1800},
+		{Date: "2025-09-09", Issue: "PROJ-124", Start: "09:00", Seconds: 900},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("GroupTimerSegments\n got %+v\nwant %+v", entries, want)
+	}
+
+	if _, err := GroupTimerSegments(segments, TimerConfig{Rounding: "soon"}, loc); err == nil {
+		t.Error("expected an invalid rounding error")
+	}
+}
+
+func TestUpdateTimerState(t *testing.T) {
+	t.Setenv("WORKLOG_STATE_DIR", t.TempDir())
+
+	start := time.Date(2025, 9, 8, 9, 0, 0, 0, time.UTC)
+	if err := UpdateTimerState(func(state *TimerState) error {
+		state.Running = &TimerSegment{Issue: "PROJ-123", Label: "meetings", Start: start}
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateTimerState: %v", err)
+	}
+
+	// A failing update leaves the saved state alone
+	failure := errors.New("no timer is running")
+	if err := UpdateTimerState(func(state *TimerState) error {
+		state.Running = nil
+		return failure
+	}); err != failure {
+		t.Fatalf("expected the update error, got %v", err)
+	}
+
+	state, err := LoadTimerState()
+	if err != nil {
+		t.Fatalf("LoadTimerState: %v", err)
+	}
+	if state.Running == nil || state.Running.Issue != "PROJ-123" || !state.Running.Start.Equal(start) {
+		t.Errorf("unexpected timer state %+v", state)
+	}
+	if got := state.Running.Duration(start.Add(90 * time.Minute)); got != 90*time.Minute {
+		t.Errorf("Duration = %v, want 1h30m", got)
+	}
+}
+
+func TestRunTimerPost(t *testing.T) {
+	settings := newTestSettings(t, "", mockjira.Failure{Method: "POST", Path: "/issue/OPS-7/worklog", Status: 503})
+
+	at := func(hour, minute int) *time.Time {
+		at := time.Date(2025, 9, 9, hour, minute, 0, 0, time.UTC)
+		return &at
+	}
+	meetings := TimerSegment{Issue: "PROJ-123", Start: *at(9, 0), End: at(10, 0)}
+	onCall := TimerSegment{Issue: "OPS-7", Start: *at(10, 0), End: at(10, 30)}
+	claimed := TimerSegment{Issue: "PROJ-124", Start: *at(11, 0), End: at(12, 0)} // By a post in progress
+	running := TimerSegment{Issue: "PROJ-123", Start: *at(13, 0)}
+	if err := UpdateTimerState(func(state *TimerState) error {
+		state.Running = &running
+		state.Finished = []TimerSegment{meetings, onCall}
+		state.Posting = []TimerSegment{claimed}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := runTimerPost(nil); code != ExitPartial {
+		t.Errorf("runTimerPost exited with %d, want %d", code, ExitPartial)
+	}
+
+	// The failed group is released back to the finished segments, the other post's claim stays
+	state, err := LoadTimerState()
+	if err != nil {
+		t.Fatalf("LoadTimerState: %v", err)
+	}
+	if state.Running == nil || !state.Running.Start.Equal(running.Start) {
+		t.Errorf("expected the running segment untouched, got %+v", state.Running)
+	}
+	if len(state.Finished) != 1 || segmentKey(state.Finished[0]) != segmentKey(onCall) {
+		t.Errorf("expected the OPS-7 segment back in the finished ones, got %+v", state.Finished)
+	}
+	if len(state.Posting) != 1 || segmentKey(state.Posting[0]) != segmentKey(claimed) {
+		t.Errorf("expected the other post's claim to stay, got %+v", state.Posting)
+	}
+
+	worklogs, err := GetMyWorklogs(settings, NewLogger("error"), "2025-09-09", "2025-09-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(worklogs) != 1 || worklogs[0].Issue != "PROJ-123" || worklogs[0].Seconds != 3600 {
+		t.Errorf("expected only the hour of PROJ-123 posted, got %+v", worklogs)
+	}
+
+	// Nothing is left to claim for a post while this one's segments are claimed
+	if err := UpdateTimerState(func(state *TimerState) error {
+		state.Posting = append(state.Posting, state.Finished...)
+		state.Finished = nil
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if code := runTimerPost(nil); code != ExitOK {
+		t.Errorf("runTimerPost without finished segments exited with %d, want %d", code, ExitOK)
+	}
+	if state, _ := LoadTimerState(); len(state.Posting) != 2 {
+		t.Errorf("expected the claims untouched, got %+v", state.Posting)
+	}
+}
+
+func TestLoadTimerStateDoesNotWrite(t *testing.T) {
+	t.Setenv("WORKLOG_STATE_DIR", t.TempDir())
+	state, err := LoadTimerState()
+	if err != nil || state.Running != nil || len(state.Finished) != 0 {
+		t.Fatalf("LoadTimerState = %+v, %v", state, err)
+	}
+	path, _ := StatePath(timerStateFile)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no timer state file written by a read, got %v", err)
+	}
+}
+
+func TestRunTimerStopUsage(t *testing.T) {
+	newTestSettings(t, "")
+	if code := runTimerStop([]string{"PROJ-123"}); code != ExitConfig {
+		t.Errorf("runTimerStop with an argument exited with %d, want %d", code, ExitConfig)
+	}
+	if code := runTimerStop(nil); code != 1 {
+		t.Errorf("runTimerStop without a running timer exited with %d, want 1", code)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/liam-witterick/jira-worklogger/jira"
+)
+
+// missingKeyPattern extracts issue keys from Jira's JQL errors about unknown or invalid keys
+var missingKeyPattern = regexp.MustCompile(`'([A-Za-z][A-Za-z0-9_]*-\d+)'`)
+
+// IssueProblem explains why worklogs can't be posted to an issue
+type IssueProblem struct {
+	Issue       string
+	Reason      string
+	Suggestions []string
+}
+
+// ValidateIssues checks with one JQL "key in (...)" query that every issue exists, is visible to
+// the user and is not in a status where logging time is disallowed. It returns the problems found,
+// keyed by upper-case issue key.
+func ValidateIssues(settings *Settings, logger *Logger, keys []string) (map[string]IssueProblem, error) {
+	problems := map[string]IssueProblem{}
+
+	pending := map[string]string{}
+	for _, key := range keys {
+		pending[strings.ToUpper(key)] = key
+	}
+
+	disallowed := map[string]bool{}
+	for _, status := range settings.DisallowedStatuses {
+		disallowed[strings.ToLower(status)] = true
+	}
+
+	// Retry only after taking unknown keys out of a rejected query
+	for len(pending) > 0 {
+		queryKeys := make([]string, 0, len(pending))
+		for key := range pending {
+			queryKeys = append(queryKeys, key)
+		}
+		sort.Strings(queryKeys)
+
+		jql := fmt.Sprintf("key in (%s)", JQLKeyList(queryKeys))
+		issues, err := NewJiraClient(settings, logger).SearchIssues(context.Background(), jql, []string{"summary", "status"})
+		if err != nil {
+			// Jira rejects the whole query when a key doesn't exist, so take the
+			// unknown keys out and ask again for the rest
+			var apiErr *jira.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+				return nil, err
+			}
+			removed := 0
+			for _, match := range missingKeyPattern.FindAllStringSubmatch(apiErr.Body, -1) {
+				key := strings.ToUpper(match[1])
+				if original, ok := pending[key]; ok {
+					problems[key] = IssueProblem{Issue: original, Reason: "does not exist or is not visible to you"}
+					delete(pending, key)
+					removed++
+				}
+			}
+			if removed == 0 {
+				return nil, err
+			}
+			continue
+		}
+
+		for _, issue := range issues {
+			key := strings.ToUpper(issue.Key)
+			original, ok := pending[key]
+			if !ok {
+				continue
+			}
+			delete(pending, key)
+
+			statusName := ""
+			if issue.Fields.Status != nil {
+				statusName = issue.Fields.Status.Name
+			}
+			if disallowed[strings.ToLower(statusName)] {
+				problems[key] = IssueProblem{Issue: original, Reason: fmt.Sprintf("is %s, logging time is not allowed in that status", statusName)}
+			}
+		}
+
+		// Keys the query didn't return don't exist or are hidden from the user
+		for key, original := range pending {
+			problems[key] = IssueProblem{Issue: original, Reason: "does not exist or is not visible to you"}
+		}
+		break
+	}
+
+	return problems, nil
+}
+
+// SuggestIssues returns up to three candidates (aliases or recent issue keys) close to a mistyped key
+func SuggestIssues(issue string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	target := strings.ToUpper(issue)
+	seen := map[string]bool{}
+	matches := []scored{}
+	for _, candidate := range candidates {
+		upper := strings.ToUpper(candidate)
+		if candidate == "" || seen[upper] || upper == target {
+			continue
+		}
+		seen[upper] = true
+
+		if distance := editDistance(target, upper); distance <= 2 {
+			matches = append(matches, scored{candidate, distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(matches) && i < 3; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// editDistance returns the optimal string alignment distance between two strings,
+// counting a transposition of adjacent characters (PRJO vs PROJ) as one edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// minInt returns the smallest of its arguments
+func minInt(values ...int) int {
+	smallest := values[0]
+	for _, v := range values[1:] {
+		if v < smallest {
+			smallest = v
+		}
+	}
+	return smallest
+}
+
+// SuggestionCandidates returns the names a mistyped issue may have meant: the aliases, their
+// issues, the assigned issues and epics, and the issues recently posted to
+func SuggestionCandidates(settings *Settings, issues []jira.Issue, epics map[string]Epic) []string {
+	candidates := []string{}
+	for alias, issue := range settings.CategoryAliases {
+		candidates = append(candidates, alias, issue)
+	}
+	for _, issue := range issues {
+		candidates = append(candidates, issue.Key)
+	}
+	for key := range epics {
+		candidates = append(candidates, key)
+	}
+	if records, err := ReadLedger(); err == nil {
+		for _, record := range records {
+			if record.Action == LedgerPost {
+				candidates = append(candidates, record.Issue)
+			}
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// RemoveProblemEntries drops the entries targeting issues with problems, and days left empty
+func RemoveProblemEntries(days []DayEntries, problems map[string]IssueProblem) []DayEntries {
+	remaining := []DayEntries{}
+	for _, day := range days {
+		kept := DayEntries{Date: day.Date}
+		for _, entry := range day.Entries {
+			if _, ok := problems[strings.ToUpper(entry.Issue)]; !ok {
+				kept.Entries = append(kept.Entries, entry)
+			}
+		}
+		if len(kept.Entries) > 0 {
+			remaining = append(remaining, kept)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestValidateIssues(t *testing.T) {
+	statuses := map[string]string{"PROJ-123": "In Progress", "PROJ-124": "In Review", "PROJ-200": "Done", "OPS-7": "Open"}
+	hidden := map[string]bool{"OPS-7": true} // Exists, but the search doesn't return it
+	invalidJQL := false
+	keyPattern := regexp.MustCompile(`"([A-Z]+-\d+)"`)
+
+	// Like Jira, the search rejects the whole query when a key doesn't exist
+	queries := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			JQL string `json:"jql"`
+		}
+		if r.URL.Path != "/rest/api/3/search/jql" || json.NewDecoder(r.Body).Decode(&request) != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		queries = append(queries, request.JQL)
+		if invalidJQL {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errorMessages": []string{"Error in the JQL Query."}})
+			return
+		}
+
+		issues := []map[string]interface{}{}
+		errorMessages := []string{}
+		for _, match := range keyPattern.FindAllStringSubmatch(request.JQL, -1) {
+			status, ok := statuses[match[1]]
+			if !ok {
+				errorMessages = append(errorMessages, fmt.Sprintf("An issue with key '%s' does not exist for field 'key'.", match[1]))
+				continue
+			}
+			if !hidden[match[1]] {
+				issues = append(issues, map[string]interface{}{"key": match[1], "fields": map[string]interface{}{"status": map[string]interface{}{"name": status}}})
+			}
+		}
+		if len(errorMessages) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errorMessages": errorMessages})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues, "isLast": true})
+	}))
+	defer server.Close()
+
+	settings := &Settings{JiraBaseURL: server.URL, JiraEmailOrUser: "jane@example.com", JiraAPIToken: "secret", APIVersion: "3"}
+	settings.DisallowedStatuses = []string{"done", "Closed"}
+
+	problems, err := ValidateIssues(settings, NewLogger("error"), []string{"PROJ-123", "proj-200", "PRJO-123", "OPS-7", "NOPE-1", "PROJ-124"})
+	if err != nil {
+		t.Fatalf("ValidateIssues: %v", err)
+	}
+
+	got := map[string]string{}
+	for key, problem := range problems {
+		got[key] = problem.Issue + " " + problem.Reason
+	}
+	want := map[string]string{
+		"PROJ-200": "proj-200 is Done, logging time is not allowed in that status",
+		"PRJO-123": "PRJO-123 does not exist or is not visible to you",
+		"NOPE-1":   "NOPE-1 does not exist or is not visible to you",
+		"OPS-7":    "OPS-7 does not exist or is not visible to you",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateIssues\n got %v\nwant %v", got, want)
+	}
+
+	// The rejected query is retried once without the unknown keys
+	if len(queries) != 2 || strings.Contains(queries[1], "NOPE-1") || strings.Contains(queries[1], "PRJO-123") || !strings.Contains(queries[1], "PROJ-200") {
+		t.Errorf("unexpected queries %q", queries)
+	}
+
+	// A 400 that names no pending key is returned as is
+	invalidJQL = true
+	if _, err := ValidateIssues(settings, NewLogger("error"), []string{"PROJ-1"}); err == nil {
+		t.Error("expected the search error")
+	}
+}
+
+func TestValidateIssuesDisallowedStatuses(t *testing.T) {
+	// PROJ-200 is Done in the default fixture, OPS-7 is Open
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{name: "default statuses", config: "", want: []string{"PROJ-200"}},
+		{name: "configured statuses", config: "defaults:\n  disallowed_statuses: [open]\n", want: []string{"OPS-7"}},
+		{name: "any status", config: "defaults:\n  disallowed_statuses: []\n", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := newTestSettings(t, tt.config)
+			problems, err := ValidateIssues(settings, NewLogger("error"), []string{"PROJ-200", "OPS-7"})
+			if err != nil {
+				t.Fatalf("ValidateIssues: %v", err)
+			}
+			got := []string{}
+			for key := range problems {
+				got = append(got, key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("problems %v, want %v", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestIssues(t *testing.T) {
+	candidates := []string{"meetings", "PROJ-123", "PROJ-124", "PROJ-4567", "OPS-7", "proj-123"}
+	sort.Strings(candidates)
+
+	tests := []struct {
+		issue string
+		want  []string
+	}{
+		{"PRJO-123", []string{"PROJ-123", "PROJ-124"}},
+		{"meetigns", []string{"meetings"}},
+		{"PROJ-123", []string{"PROJ-124"}},
+		{"XYZ-999", []string{}},
+	}
+	for _, tt := range tests {
+		if got := SuggestIssues(tt.issue, candidates); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SuggestIssues(%q) = %q, want %q", tt.issue, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveProblemEntries(t *testing.T) {
+	days := []DayEntries{
+		{Date: "2025-09-08", Entries: []TimeEntry{{Issue: "PROJ-123"}, {Issue: "proj-200"}}},
+		{Date: "2025-09-09", Entries: []TimeEntry{{Issue: "PROJ-200"}}},
+	}
+	remaining := RemoveProblemEntries(days, map[string]IssueProblem{"PROJ-200": {Issue: "PROJ-200"}})
+	if len(remaining) != 1 || len(remaining[0].Entries) != 1 || remaining[0].Entries[0].Issue != "PROJ-123" {
+		t.Errorf("RemoveProblemEntries = %+v", remaining)
+	}
+}